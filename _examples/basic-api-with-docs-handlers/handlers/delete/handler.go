@@ -26,7 +26,7 @@ func Handler(store StoreProductAdapter) router.HandlerFunc {
 			return
 		}
 
-		c.Status(http.StatusNoContent)
+		c.NoContent()
 	}
 }
 
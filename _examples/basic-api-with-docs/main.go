@@ -473,5 +473,5 @@ func deleteProduct(c *router.Context, store *ProductStore) {
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	c.NoContent()
 }
@@ -54,7 +54,7 @@ func main() {
 	r.DELETE("/tasks/{id}", deleteTask)
 
 	// Group routes by version
-	r.Group("/v2", func(r *router.Router) {
+	r.Version("v2", func(r *router.Router) {
 		r.GET("/tasks", listTasksV2)
 	})
 
@@ -190,7 +190,7 @@ func deleteTask(c *router.Context) {
 		if task.ID == id {
 			// Remove task from slice
 			tasks = append(tasks[:i], tasks[i+1:]...)
-			c.Status(http.StatusNoContent)
+			c.NoContent()
 			return
 		}
 	}
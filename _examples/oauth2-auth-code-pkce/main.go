@@ -88,6 +88,8 @@ func main() {
 			docs.WithTags("Todos"),
 			docs.WithSummary("List todos"),
 			docs.WithDescription("Returns the authenticated user's todo items"),
+			docs.WithQueryParam("offset", "integer", false, "Number of items to skip", 0),
+			docs.WithQueryParam("limit", "integer", false, "Maximum number of items to return", 20),
 			docs.WithResponse(http.StatusOK, "Todos retrieved successfully"),
 			docs.WithJSONResponse[[]TodoItem](http.StatusOK, "Todo items"),
 			docs.WithResponse(http.StatusUnauthorized, "Unauthorized"),
@@ -329,7 +331,9 @@ func listTodos(c *router.Context) {
 		},
 	}
 
-	c.JSON(http.StatusOK, todos)
+	offset, limit := c.Paginate(router.PageDefaults{Limit: 20, MaxLimit: 100})
+	c.SetPaginationHeaders(len(todos), offset, limit)
+	c.JSON(http.StatusOK, todos[min(offset, len(todos)):min(offset+limit, len(todos))])
 }
 
 func createTodo(c *router.Context) {
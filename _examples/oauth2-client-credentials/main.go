@@ -41,6 +41,11 @@ type ErrorResponse struct {
 func main() {
 	r := router.New()
 
+	// Reject requests while in maintenance mode, except for the health check
+	// and the endpoints that toggle maintenance
+	r.Use(router.Maintenance(func() bool { return maintenanceMode }, 5*time.Minute,
+		"/health", "/maintenance/start", "/maintenance/end"))
+
 	// Public endpoints
 	r.GET("/health", healthCheck,
 		docs.WithTags("Health"),
@@ -1,8 +1,12 @@
 package docs
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
+	"time"
 
+	"github.com/joakimcarlsson/go-router/jsonpatch"
 	"github.com/joakimcarlsson/go-router/metadata"
 )
 
@@ -46,6 +50,7 @@ func WithTags(tags ...string) RouteOption {
 
 // WithParameter adds a parameter to the route.
 // This is a generic function that can add any type of parameter (path, query, header, etc.).
+// The example is documented on the parameter itself rather than on its schema.
 //
 // Parameters:
 //   - name: The parameter name
@@ -61,14 +66,44 @@ func WithParameter(name, in, typ string, required bool, description string, exam
 			In:          in,
 			Required:    required,
 			Description: description,
-			Schema: metadata.Schema{
-				Type:    typ,
-				Example: example,
-			},
+			Schema:      metadata.Schema{Type: typ},
+			Example:     example,
 		})
 	}
 }
 
+// WithParameterRef adds a reference to a reusable parameter component
+// registered on the generator with WithParameterComponent, instead of
+// describing the parameter inline.
+func WithParameterRef(name string) RouteOption {
+	return func(m *metadata.RouteMetadata) {
+		m.Parameters = append(m.Parameters, metadata.Parameter{Ref: name})
+	}
+}
+
+// WithParamExamples adds named examples to a parameter declared by an
+// earlier option, such as WithQueryParam. The generator emits them as
+// "examples" for OpenAPI 3.1 and uses the first one, by name, as the single
+// "example" for OpenAPI 3.0.
+// It panics if the route has no parameter with the given name.
+func WithParamExamples(name string, examples map[string]interface{}) RouteOption {
+	return func(m *metadata.RouteMetadata) {
+		for i := range m.Parameters {
+			if m.Parameters[i].Name != name {
+				continue
+			}
+			if m.Parameters[i].Examples == nil {
+				m.Parameters[i].Examples = make(map[string]interface{}, len(examples))
+			}
+			for k, v := range examples {
+				m.Parameters[i].Examples[k] = v
+			}
+			return
+		}
+		panic(fmt.Sprintf("docs: WithParamExamples: route has no parameter %q, declare it first", name))
+	}
+}
+
 // WithQueryParam adds a query parameter to the route.
 // Query parameters are appended to the URL after a question mark.
 //
@@ -82,6 +117,27 @@ func WithQueryParam(name, typ string, required bool, description string, example
 	return WithParameter(name, "query", typ, required, description, example)
 }
 
+// WithQueryParamDefault adds an optional query parameter to the route that
+// takes a default value when it is omitted, such as a page size:
+//
+//	docs.WithQueryParamDefault("limit", "integer", false, "Page size", 20)
+//
+// The default is documented as the "default" of the parameter's schema and
+// is available to handlers through the parameters of c.RouteMetadata().
+//
+// Parameters:
+//   - name: The parameter name
+//   - typ: The parameter type (string, integer, boolean, etc.)
+//   - required: Whether the parameter is required
+//   - description: A description of the parameter
+//   - defaultVal: The value used when the parameter is omitted
+func WithQueryParamDefault(name, typ string, required bool, description string, defaultVal interface{}) RouteOption {
+	return func(m *metadata.RouteMetadata) {
+		WithParameter(name, "query", typ, required, description, nil)(m)
+		m.Parameters[len(m.Parameters)-1].Schema.Default = defaultVal
+	}
+}
+
 // WithPathParam adds a path parameter to the route.
 // Path parameters are part of the URL path and are denoted by a colon prefix in the route pattern.
 //
@@ -107,6 +163,15 @@ func WithHeaderParam(name string, required bool, description string, example int
 	return WithParameter(name, "header", "string", required, description, example)
 }
 
+// WithRequestBodyRef references a reusable request body component
+// registered on the generator with WithRequestBodyComponent, instead of
+// describing the request body inline.
+func WithRequestBodyRef(name string) RouteOption {
+	return func(m *metadata.RouteMetadata) {
+		m.RequestBody = &metadata.RequestBody{Ref: name}
+	}
+}
+
 // WithRequestBody adds a request body with a specific content type.
 // This defines the schema and requirements for the request body.
 //
@@ -151,6 +216,42 @@ func WithJSONRequestBody[T any](required bool, description string) RouteOption {
 	}
 }
 
+// WithJSONPatchRequestBody adds a required JSON Patch (RFC 6902) request body
+// with the application/json-patch+json content type, the body read by
+// Context.BindJSONPatch6902. It documents the list of operations, so PATCH
+// routes don't need a schema of their own.
+//
+// Parameters:
+//   - description: A description of the request body
+func WithJSONPatchRequestBody(description string) RouteOption {
+	operation := metadata.Schema{
+		Type: "object",
+		Properties: map[string]metadata.Schema{
+			"op": {
+				Type: "string",
+				Enum: []interface{}{"add", "remove", "replace", "move", "copy", "test"},
+			},
+			"path": {
+				Type:        "string",
+				Description: "JSON Pointer (RFC 6901) of the location to change",
+				Example:     "/title",
+			},
+			"from": {
+				Type:        "string",
+				Description: "JSON Pointer of the source location of move and copy",
+			},
+			"value": {
+				Description: "Value of add, replace and test",
+			},
+		},
+		Required: []string{"op", "path"},
+	}
+	return WithRequestBody(jsonpatch.ContentType, metadata.Schema{
+		Type:  "array",
+		Items: &operation,
+	}, true, description)
+}
+
 // FormFieldSpec defines the specification for a form field
 type FormFieldSpec struct {
 	Description string
@@ -313,7 +414,9 @@ func WithMultipartFormStruct[T any](description string) RouteOption {
 }
 
 // WithResponse adds a response to the route.
-// This defines a response without any content schema.
+// This defines a response without any content schema. If the status code is
+// already documented, only its description is updated and the existing
+// content is kept.
 //
 // Parameters:
 //   - statusCode: The HTTP status code for the response
@@ -324,14 +427,15 @@ func WithResponse(statusCode int, description string) RouteOption {
 		if m.Responses == nil {
 			m.Responses = make(map[string]metadata.Response)
 		}
-		m.Responses[code] = metadata.Response{
-			Description: description,
-		}
+		resp := m.Responses[code]
+		resp.Description = description
+		m.Responses[code] = resp
 	}
 }
 
 // WithJSONResponse adds a JSON response with schema inferred from the provided type.
 // This uses Go's reflect package to generate a schema from the type parameter T.
+// If the status code is already documented, its description is kept.
 //
 // Type Parameters:
 //   - T: The Go type to use for the response schema
@@ -340,21 +444,107 @@ func WithResponse(statusCode int, description string) RouteOption {
 //   - statusCode: The HTTP status code for the response
 //   - description: A description of the response
 func WithJSONResponse[T any](statusCode int, description string) RouteOption {
-	return func(m *metadata.RouteMetadata) {
-		t := reflect.TypeOf((*T)(nil)).Elem()
-		schema := SchemaFromType(t)
+	return jsonResponse[T](metadata.StatusCodeToString(statusCode), description)
+}
 
-		code := metadata.StatusCodeToString(statusCode)
+// WithDefaultResponse adds the "default" JSON response, which documents the
+// response for any status code not described explicitly, typically the
+// common error body.
+//
+// Type Parameters:
+//   - T: The Go type to use for the response schema
+//
+// Parameters:
+//   - description: A description of the response
+func WithDefaultResponse[T any](description string) RouteOption {
+	return jsonResponse[T]("default", description)
+}
+
+// WithRangeResponse adds a JSON response for a whole range of status codes,
+// such as "4XX" for all client errors. Explicit status codes take precedence
+// over a range in the documentation.
+// It panics if rangeCode is not one of "1XX" through "5XX".
+//
+// Type Parameters:
+//   - T: The Go type to use for the response schema
+//
+// Parameters:
+//   - rangeCode: The status code range, e.g. "2XX" or "4XX"
+//   - description: A description of the response
+func WithRangeResponse[T any](rangeCode string, description string) RouteOption {
+	code := strings.ToUpper(rangeCode)
+	if len(code) != 3 || code[0] < '1' || code[0] > '5' || code[1:] != "XX" {
+		panic(fmt.Sprintf("docs: invalid response range %q, expected 1XX through 5XX", rangeCode))
+	}
+	return jsonResponse[T](code, description)
+}
+
+// jsonResponse adds a JSON response with a schema inferred from T under the given response key.
+// A description already documented for the key is kept.
+func jsonResponse[T any](code, description string) RouteOption {
+	return jsonSchemaResponse(code, description, SchemaFromType(reflect.TypeOf((*T)(nil)).Elem()))
+}
+
+// jsonSchemaResponse adds a JSON response with the given schema under the
+// given response key. A description already documented for the key is kept.
+func jsonSchemaResponse(code, description string, schema metadata.Schema) RouteOption {
+	return func(m *metadata.RouteMetadata) {
 		if m.Responses == nil {
 			m.Responses = make(map[string]metadata.Response)
 		}
-		m.Responses[code] = metadata.Response{
-			Description: description,
-			Content: map[string]metadata.MediaType{
-				"application/json": {Schema: schema},
-			},
+		resp := m.Responses[code]
+		if resp.Description == "" {
+			resp.Description = description
 		}
+		if resp.Content == nil {
+			resp.Content = make(map[string]metadata.MediaType)
+		}
+		resp.Content["application/json"] = metadata.MediaType{Schema: schema}
+		m.Responses[code] = resp
+	}
+}
+
+// WithPagedJSONResponse adds a JSON response for a page of a cursor paginated
+// list. T's schema is wrapped in a {data, nextCursor, hasMore} envelope so a
+// response type doesn't have to be declared per endpoint. When T is a struct
+// the envelope is documented as the "Paged" + T component, next to T's own,
+// following the name the generator assigns to T, such as one given by a
+// schema namer. The envelope's shape is that of metadata.Page[T].
+// If the status code is already documented, its description is kept.
+//
+// Type Parameters:
+//   - T: The Go type of the items in the page
+//
+// Parameters:
+//   - statusCode: The HTTP status code for the response
+//   - description: A description of the response
+func WithPagedJSONResponse[T any](statusCode int, description string) RouteOption {
+	item := SchemaFromType(reflect.TypeOf((*T)(nil)).Elem())
+
+	envelope := metadata.Schema{
+		Type: "object",
+		Properties: map[string]metadata.Schema{
+			"data": {
+				Type:     "array",
+				Items:    &item,
+				TypeName: "[]" + item.TypeName,
+			},
+			"nextCursor": {
+				Type:        "string",
+				Description: "Cursor of the next page, empty on the last page",
+			},
+			"hasMore": {
+				Type:        "boolean",
+				Description: "Whether there are more items after this page",
+			},
+		},
+		Required: []string{"data", "hasMore"},
+		GoType:   reflect.TypeOf((*metadata.Page[T])(nil)).Elem(),
+	}
+	if item.Type == "object" && item.TypeName != "" {
+		envelope.TypeName = "Paged" + item.TypeName
 	}
+	return jsonSchemaResponse(metadata.StatusCodeToString(statusCode), description, envelope)
 }
 
 // WithDeprecated marks a route as deprecated.
@@ -375,6 +565,135 @@ func WithDeprecated(message string) RouteOption {
 	}
 }
 
+// WithSunset marks a route as deprecated and announces the date it will be removed.
+// Besides flagging the operation as deprecated in the documentation, the router
+// sends the Deprecation and Sunset (RFC 8594) response headers on every response.
+//
+// Parameters:
+//   - sunset: The date after which the route will no longer be available
+func WithSunset(sunset time.Time) RouteOption {
+	return func(m *metadata.RouteMetadata) {
+		m.Deprecated = true
+		m.Sunset = sunset
+	}
+}
+
+// WithCacheControl sets the caching policy of a route.
+// The router sends the directive as the Cache-Control response header and
+// the header is documented on the route's successful responses.
+//
+// Parameters:
+//   - directive: The Cache-Control directive (e.g., "public, max-age=300")
+func WithCacheControl(directive string) RouteOption {
+	return func(m *metadata.RouteMetadata) {
+		m.CacheControl = directive
+	}
+}
+
+// WithMaxRequestBytes limits the size of the route's request bodies.
+// The router rejects larger bodies with 413 Request Entity Too Large and the
+// limit is documented as the "x-max-request-bytes" extension and in the
+// route's description.
+//
+// Parameters:
+//   - n: The maximum request body size in bytes
+func WithMaxRequestBytes(n int64) RouteOption {
+	return func(m *metadata.RouteMetadata) {
+		m.MaxRequestBytes = n
+		if m.Extensions == nil {
+			m.Extensions = make(map[string]interface{})
+		}
+		m.Extensions["x-max-request-bytes"] = n
+	}
+}
+
+// WithExcludeFromDocs hides a route from the generated API documentation.
+// The route is still registered and served as usual. This is used for
+// self-referential routes such as the OpenAPI spec and Swagger UI endpoints.
+func WithExcludeFromDocs() RouteOption {
+	return func(m *metadata.RouteMetadata) {
+		m.ExcludeFromDocs = true
+	}
+}
+
+// WithEnabled registers the route only if enabled is true, for endpoints
+// shipped behind a feature flag. A disabled route is neither served nor
+// documented, as if it had not been registered:
+//
+//	r.POST("/exports", createExport, docs.WithEnabled(flags.Exports))
+func WithEnabled(enabled bool) RouteOption {
+	return func(m *metadata.RouteMetadata) {
+		m.Disabled = !enabled
+	}
+}
+
+// WithNoTimeout exempts the route from the deadline set on request contexts
+// by Router.WithDefaultTimeout, for long-lived responses such as server-sent
+// events and streaming downloads.
+func WithNoTimeout() RouteOption {
+	return func(m *metadata.RouteMetadata) {
+		m.NoTimeout = true
+	}
+}
+
+// WithAnnotation attaches a key/value annotation to the route. Annotations
+// are not part of the documentation; they carry cross-cutting settings such
+// as rate limit tiers or audit levels, which middleware reads with
+// Context.RouteAnnotation.
+//
+// Parameters:
+//   - key: The annotation name (e.g., "rate-limit-tier")
+//   - value: The annotation value
+func WithAnnotation(key, value string) RouteOption {
+	return func(m *metadata.RouteMetadata) {
+		if m.Annotations == nil {
+			m.Annotations = make(map[string]string)
+		}
+		m.Annotations[key] = value
+	}
+}
+
+// WithOperationServer documents a server the route is served from, for an
+// operation that lives on a different host than the rest of the API, such
+// as a legacy upload endpoint. The route's servers replace the API's servers
+// for this operation; call it several times to list more than one.
+//
+// Parameters:
+//   - url: The URL of the server
+//   - description: An optional description of the server
+func WithOperationServer(url, description string) RouteOption {
+	return func(m *metadata.RouteMetadata) {
+		m.Servers = append(m.Servers, metadata.Server{URL: url, Description: description})
+	}
+}
+
+// WithExtension adds a vendor extension to the route's operation.
+// Extensions are read by tooling such as code generators and API gateways.
+// The "x-" prefix required by OpenAPI is added if the key doesn't have it.
+//
+// Parameters:
+//   - key: The extension name (e.g., "x-internal")
+//   - value: The extension value, serialized as JSON
+func WithExtension(key string, value interface{}) RouteOption {
+	if !strings.HasPrefix(key, "x-") {
+		key = "x-" + key
+	}
+	return func(m *metadata.RouteMetadata) {
+		if m.Extensions == nil {
+			m.Extensions = make(map[string]interface{})
+		}
+		m.Extensions[key] = value
+	}
+}
+
+// WithTryItOutDisabled hides the "Try it out" button of the route in
+// Swagger UI, for destructive or expensive operations that shouldn't be
+// called from the documentation. It sets the x-tryItOut extension to false,
+// which the Swagger UI served by the swagger package honors.
+func WithTryItOutDisabled() RouteOption {
+	return WithExtension("x-tryItOut", false)
+}
+
 // WithSecurity adds security requirements to a route.
 // Security requirements define the authentication methods that can be used
 // to access the route.
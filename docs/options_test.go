@@ -0,0 +1,48 @@
+package docs_test
+
+import (
+	"testing"
+
+	"github.com/joakimcarlsson/go-router/docs"
+	"github.com/joakimcarlsson/go-router/metadata"
+)
+
+type widget struct {
+	Name string `json:"name"`
+}
+
+func TestResponseOptionsMerge(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []docs.RouteOption
+		description string
+	}{
+		{
+			"WithResponse after WithJSONResponse",
+			[]docs.RouteOption{docs.WithJSONResponse[widget](200, "Widget"), docs.WithResponse(200, "The widget")},
+			"The widget",
+		},
+		{
+			"WithJSONResponse after WithResponse",
+			[]docs.RouteOption{docs.WithResponse(200, "The widget"), docs.WithJSONResponse[widget](200, "Widget")},
+			"The widget",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m metadata.RouteMetadata
+			for _, opt := range tt.opts {
+				opt(&m)
+			}
+
+			resp := m.Responses["200"]
+			if resp.Description != tt.description {
+				t.Fatalf("expected description %q, got %q", tt.description, resp.Description)
+			}
+			if schema := resp.Content["application/json"].Schema; schema.Properties["name"].Type != "string" {
+				t.Fatalf("expected the JSON schema to be kept, got %+v", resp.Content)
+			}
+		})
+	}
+}
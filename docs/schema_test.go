@@ -0,0 +1,129 @@
+package docs_test
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/go-router/docs"
+)
+
+func TestSchemaFromStdlibTypes(t *testing.T) {
+	tests := []struct {
+		name   string
+		typ    reflect.Type
+		want   string
+		format string
+	}{
+		{"time.Time", reflect.TypeOf(time.Time{}), "string", "date-time"},
+		{"time.Duration", reflect.TypeOf(time.Duration(0)), "integer", "int64"},
+		{"json.RawMessage", reflect.TypeOf(json.RawMessage(nil)), "", ""},
+		{"url.URL", reflect.TypeOf(url.URL{}), "string", "uri"},
+		{"[]byte", reflect.TypeOf([]byte(nil)), "string", "byte"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := docs.SchemaFromType(tt.typ)
+			if schema.Type != tt.want || schema.Format != tt.format {
+				t.Fatalf("expected %s/%s, got %s/%s", tt.want, tt.format, schema.Type, schema.Format)
+			}
+			if schema.Items != nil || schema.Properties != nil {
+				t.Fatalf("expected a scalar schema, got %+v", schema)
+			}
+		})
+	}
+}
+
+func TestSchemaFromStructWithDuration(t *testing.T) {
+	type Job struct {
+		Timeout time.Duration `json:"timeout"`
+	}
+
+	prop := docs.SchemaFromType(reflect.TypeOf(Job{})).Properties["timeout"]
+	if prop.Type != "integer" || prop.Description != "Duration in nanoseconds" {
+		t.Fatalf("unexpected duration property %+v", prop)
+	}
+}
+
+func TestSchemaFromStructWithURL(t *testing.T) {
+	type Webhook struct {
+		Target   url.URL  `json:"target"`
+		Fallback *url.URL `json:"fallback"`
+	}
+
+	schema := docs.SchemaFromType(reflect.TypeOf(Webhook{}))
+	for _, name := range []string{"target", "fallback"} {
+		if prop := schema.Properties[name]; prop.Type != "string" || prop.Format != "uri" || prop.Properties != nil {
+			t.Fatalf("expected %s to be a uri string, got %+v", name, prop)
+		}
+	}
+	if example, _ := schema.Example.(map[string]interface{}); example["target"] != "https://example.com" {
+		t.Fatalf("expected a uri example, got %v", schema.Example)
+	}
+}
+
+func TestSchemaItemValidationRules(t *testing.T) {
+	type Review struct {
+		Scores []int    `json:"scores" validate:"items.min=0,items.max=100"`
+		Tags   []string `json:"tags" validate:"items.min=2,items.max=20"`
+		Rating int      `json:"rating" validate:"min=1,max=5"`
+	}
+
+	props := docs.SchemaFromType(reflect.TypeOf(Review{})).Properties
+
+	scores := props["scores"]
+	if scores.Minimum != nil || scores.Maximum != nil {
+		t.Fatalf("expected no range on the array itself, got %+v", scores)
+	}
+	if items := scores.Items; items.Minimum == nil || *items.Minimum != 0 || items.Maximum == nil || *items.Maximum != 100 {
+		t.Fatalf("expected items between 0 and 100, got %+v", items)
+	}
+	if items := props["tags"].Items; items.MinLength == nil || *items.MinLength != 2 || items.MaxLength == nil || *items.MaxLength != 20 {
+		t.Fatalf("expected item lengths between 2 and 20, got %+v", items)
+	}
+	if rating := props["rating"]; rating.Minimum == nil || *rating.Minimum != 1 || rating.Maximum == nil || *rating.Maximum != 5 {
+		t.Fatalf("expected a rating between 1 and 5, got %+v", rating)
+	}
+}
+
+func TestSchemaExampleTags(t *testing.T) {
+	type Product struct {
+		Name     string   `json:"name" example:"Espresso cup"`
+		Price    float64  `json:"price" example:"12.5"`
+		Stock    int      `json:"stock" example:"250"`
+		Active   bool     `json:"active" example:"false"`
+		Discount *int     `json:"discount,omitempty" example:"10"`
+		Colors   []string `json:"colors" example:"white, black"`
+		SKU      string   `json:"sku"`
+	}
+
+	schema := docs.SchemaFromType(reflect.TypeOf(Product{}))
+	data, err := json.Marshal(schema.Example)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"active":false,"colors":["white","black"],"discount":10,"name":"Espresso cup","price":12.5,"sku":"example","stock":250}`
+	if string(data) != want {
+		t.Fatalf("expected example %s, got %s", want, data)
+	}
+	if example := schema.Properties["stock"].Example; example != int64(250) {
+		t.Fatalf("expected the stock property example to be 250, got %#v", example)
+	}
+}
+
+func TestSchemaInvalidExampleTagPanics(t *testing.T) {
+	type Order struct {
+		Quantity int `json:"quantity" example:"many"`
+	}
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, "docs_test.Order.Quantity") {
+			t.Fatalf("expected the panic to name the struct field, got %q", msg)
+		}
+	}()
+	docs.SchemaFromType(reflect.TypeOf(Order{}))
+}
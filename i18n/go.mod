@@ -0,0 +1,10 @@
+module github.com/joakimcarlsson/go-router/i18n
+
+go 1.22.0
+
+require (
+	github.com/joakimcarlsson/go-router v0.0.0-00010101000000-000000000000
+	golang.org/x/text v0.22.0
+)
+
+replace github.com/joakimcarlsson/go-router => ../
@@ -0,0 +1,86 @@
+// Package i18n localizes responses to the language a client prefers,
+// based on the Accept-Language header. It is a separate package so that the
+// router itself doesn't depend on golang.org/x/text, and a module of its
+// own so that only programs importing it download x/text.
+//
+// Translate the problem responses of a router with a catalog:
+//
+//	catalog := i18n.Catalog{
+//		language.German: {
+//			"Too Many Requests":   "Zu viele Anfragen",
+//			"rate limit exceeded": "Anfragelimit überschritten",
+//		},
+//	}
+//	r.WithProblemLocalizer(i18n.ProblemLocalizer(language.English, catalog))
+package i18n
+
+import (
+	"sort"
+
+	"golang.org/x/text/language"
+
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+// PreferredLanguage returns the language among supported that best matches
+// the request's Accept-Language header, taking quality values into account.
+// The first supported language is returned when nothing matches or the
+// header is missing, and language.Und when supported is empty.
+func PreferredLanguage(c *router.Context, supported ...language.Tag) language.Tag {
+	if len(supported) == 0 {
+		return language.Und
+	}
+	return preferredLanguage(c, language.NewMatcher(supported), supported)
+}
+
+// preferredLanguage is PreferredLanguage with a matcher built from the
+// non-empty supported languages, so callers can reuse it across requests.
+func preferredLanguage(c *router.Context, matcher language.Matcher, supported []language.Tag) language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	if err != nil || len(tags) == 0 {
+		return supported[0]
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return supported[0]
+	}
+	return supported[index]
+}
+
+// Catalog holds translated messages: for each language, a map from a
+// message in the default language to its translation.
+type Catalog map[language.Tag]map[string]string
+
+// ProblemLocalizer returns a localizer for Router.WithProblemLocalizer that
+// translates the title and detail of problem responses to the language the
+// client prefers among fallback, the language the messages are written in,
+// and the languages of the catalog. Messages without a translation are kept
+// as is. The chosen language is sent in the Content-Language header.
+func ProblemLocalizer(fallback language.Tag, catalog Catalog) router.ProblemLocalizer {
+	supported := []language.Tag{fallback}
+	for tag := range catalog {
+		if tag != fallback {
+			supported = append(supported, tag)
+		}
+	}
+	sort.Slice(supported[1:], func(i, j int) bool {
+		return supported[i+1].String() < supported[j+1].String()
+	})
+	matcher := language.NewMatcher(supported)
+
+	return func(c *router.Context, problem router.ProblemDetails) router.ProblemDetails {
+		tag := preferredLanguage(c, matcher, supported)
+		c.SetHeader("Content-Language", tag.String())
+		c.AddHeader("Vary", "Accept-Language")
+
+		messages := catalog[tag]
+		if title, ok := messages[problem.Title]; ok {
+			problem.Title = title
+		}
+		if detail, ok := messages[problem.Detail]; ok {
+			problem.Detail = detail
+		}
+		return problem
+	}
+}
@@ -0,0 +1,81 @@
+package i18n_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/text/language"
+
+	"github.com/joakimcarlsson/go-router/i18n"
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+func TestPreferredLanguage(t *testing.T) {
+	supported := []language.Tag{language.English, language.German, language.French}
+	tests := []struct {
+		header string
+		want   language.Tag
+	}{
+		{"fr;q=0.5, de;q=0.9, en;q=0.1", language.German},
+		{"fr-CH, fr;q=0.9", language.French},
+		{"ja", language.English},
+		{"", language.English},
+	}
+
+	for _, tt := range tests {
+		var got language.Tag
+		r := router.New()
+		r.GET("/", func(c *router.Context) {
+			got = i18n.PreferredLanguage(c, supported...)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			req.Header.Set("Accept-Language", tt.header)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		if got != tt.want {
+			t.Errorf("PreferredLanguage(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestProblemLocalizer(t *testing.T) {
+	catalog := i18n.Catalog{
+		language.German: {
+			"Not Found":      "Nicht gefunden",
+			"todo not found": "Aufgabe nicht gefunden",
+		},
+	}
+	r := router.New().WithProblemLocalizer(i18n.ProblemLocalizer(language.English, catalog))
+	r.GET("/todos/{id}", func(c *router.Context) {
+		c.Problem(http.StatusNotFound, router.NewProblem(http.StatusNotFound, "todo not found"))
+	})
+
+	tests := []struct {
+		header, language, title, detail string
+	}{
+		{"de-DE, en;q=0.5", "de", "Nicht gefunden", "Aufgabe nicht gefunden"},
+		{"en", "en", "Not Found", "todo not found"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/todos/1", nil)
+		req.Header.Set("Accept-Language", tt.header)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Content-Language"); got != tt.language {
+			t.Errorf("%q: Content-Language = %q, want %q", tt.header, got, tt.language)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body["title"] != tt.title || body["detail"] != tt.detail {
+			t.Errorf("%q: problem = %v, want title %q and detail %q", tt.header, body, tt.title, tt.detail)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package integration
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/joakimcarlsson/go-router/openapi"
+)
+
+// untaggedGroup is the group of operations without tags, named like in Swagger UI
+const untaggedGroup = "default"
+
+// IndexOperation is an operation listed in the API index.
+type IndexOperation struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	OperationID string `json:"operationId,omitempty"`
+	Summary     string `json:"summary,omitempty"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+}
+
+// IndexGroup holds the operations of a tag in the API index.
+type IndexGroup struct {
+	Tag        string           `json:"tag"`
+	Operations []IndexOperation `json:"operations"`
+}
+
+// Index lists the documented operations grouped by tag. An operation with
+// several tags is listed in each of their groups and operations without tags
+// are listed in the "default" group, which comes last.
+func (a *RouterOpenAPIAdapter) Index() []IndexGroup {
+	groups := make(map[string][]IndexOperation)
+	for _, route := range a.ExtractRouteInfo() {
+		op := IndexOperation{
+			Method:      route.Method(),
+			Path:        route.Path(),
+			OperationID: route.OperationID(),
+			Summary:     route.Summary(),
+			Deprecated:  route.IsDeprecated(),
+		}
+		tags := route.Tags()
+		if len(tags) == 0 {
+			tags = []string{untaggedGroup}
+		}
+		for _, tag := range tags {
+			groups[tag] = append(groups[tag], op)
+		}
+	}
+
+	index := make([]IndexGroup, 0, len(groups))
+	for tag, ops := range groups {
+		sort.Slice(ops, func(i, j int) bool {
+			if ops[i].Path != ops[j].Path {
+				return ops[i].Path < ops[j].Path
+			}
+			return ops[i].Method < ops[j].Method
+		})
+		index = append(index, IndexGroup{Tag: tag, Operations: ops})
+	}
+	sort.Slice(index, func(i, j int) bool {
+		if (index[i].Tag == untaggedGroup) != (index[j].Tag == untaggedGroup) {
+			return index[j].Tag == untaggedGroup
+		}
+		return index[i].Tag < index[j].Tag
+	})
+	return index
+}
+
+// ServeIndex serves a lightweight index of the API: its operations grouped
+// by tag, with their methods, paths and summaries. It is an HTML page, or
+// JSON when the request accepts application/json, and needs no assets so it
+// can serve as a fallback to Swagger UI.
+func (a *RouterOpenAPIAdapter) ServeIndex(w http.ResponseWriter, r *http.Request) {
+	index := a.Index()
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		if err := openapi.WriteJSON(w, index); err != nil {
+			http.Error(w, "Failed to write API index", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	data := struct {
+		Title  string
+		Groups []IndexGroup
+	}{
+		Title:  a.Generator.Info().Title,
+		Groups: index,
+	}
+	var buf bytes.Buffer
+	if err := indexTemplate.Execute(&buf, data); err != nil {
+		http.Error(w, "Failed to render API index", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <title>{{.Title}}</title>
+  <style>
+    body { font-family: sans-serif; margin: 2em; }
+    table { border-collapse: collapse; margin-bottom: 2em; }
+    td { padding: 0.25em 1em 0.25em 0; }
+    .method { font-family: monospace; font-weight: bold; }
+    .path { font-family: monospace; }
+    .deprecated { text-decoration: line-through; }
+  </style>
+</head>
+<body>
+  <h1>{{.Title}}</h1>
+  {{range .Groups}}
+  <h2>{{.Tag}}</h2>
+  <table>
+    {{range .Operations}}
+    <tr{{if .Deprecated}} class="deprecated"{{end}}>
+      <td class="method">{{.Method}}</td>
+      <td class="path">{{.Path}}</td>
+      <td>{{.Summary}}</td>
+    </tr>
+    {{end}}
+  </table>
+  {{end}}
+</body>
+</html>
+`))
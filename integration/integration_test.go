@@ -0,0 +1,298 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/go-router/docs"
+	"github.com/joakimcarlsson/go-router/integration"
+	"github.com/joakimcarlsson/go-router/openapi"
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+// fetchSpec requests the spec served at path and decodes it.
+func fetchSpec(t *testing.T, r *router.Router, path string) openapi.Spec {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	if w.Code != 200 {
+		t.Fatalf("expected 200 from %s, got %d", path, w.Code)
+	}
+
+	var spec openapi.Spec
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+func TestSetupExcludesDocRoutesOnly(t *testing.T) {
+	r := router.New()
+	r.GET("/openapi.json", func(c *router.Context) {
+		c.JSON(200, map[string]string{"legit": "route"})
+	})
+
+	opts := integration.DefaultSetupOptions()
+	opts.SpecPath = "/spec.json"
+	opts.DocsPath = "/api-docs"
+	if err := integration.Setup(r, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := fetchSpec(t, r, "/spec.json")
+	if _, ok := spec.Paths["/openapi.json"]; !ok {
+		t.Fatal("expected the user's /openapi.json route to be documented")
+	}
+	for _, path := range []string{"/spec.json", "/api-docs"} {
+		if _, ok := spec.Paths[path]; ok {
+			t.Fatalf("expected %s to be excluded from the spec", path)
+		}
+	}
+}
+
+func TestSetupSpecCORS(t *testing.T) {
+	r := router.New()
+	opts := integration.DefaultSetupOptions()
+	opts.SpecCORSOrigin = "*"
+	if err := integration.Setup(r, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("expected CORS header on spec, got %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no CORS header on docs page, got %q", got)
+	}
+}
+
+func TestSetupServersFromRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		trusted bool
+		proto   string
+		want    string
+	}{
+		{"untrusted", false, "https, http", "http://api.example.com"},
+		{"trusted", true, "https, http", "https://proxy.example.com"},
+		{"trusted with invalid scheme", true, "javascript", "http://proxy.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := router.New()
+			opts := integration.DefaultSetupOptions()
+			opts.ServersFromRequest = true
+			opts.TrustForwardedHeaders = tt.trusted
+			if err := integration.Setup(r, opts); err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest("GET", "/openapi.json", nil)
+			req.Host = "api.example.com"
+			req.Header.Set("X-Forwarded-Proto", tt.proto)
+			req.Header.Set("X-Forwarded-Host", "proxy.example.com")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			var spec openapi.Spec
+			if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+				t.Fatal(err)
+			}
+			if len(spec.Servers) != 1 || spec.Servers[0].URL != tt.want {
+				t.Fatalf("expected server %q, got %+v", tt.want, spec.Servers)
+			}
+		})
+	}
+}
+
+func TestServersFromRequestKeepsExplicitServers(t *testing.T) {
+	r := router.New()
+	generator := openapi.NewGenerator(openapi.Info{Title: "Test API", Version: "1.0.0"})
+	generator.WithServer("https://prod.example.com", "Production")
+	adapter := integration.NewRouterOpenAPIAdapter(r, generator)
+	adapter.ServersFromRequest = true
+
+	w := httptest.NewRecorder()
+	adapter.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+
+	var spec openapi.Spec
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if len(spec.Servers) != 1 || spec.Servers[0].URL != "https://prod.example.com" {
+		t.Fatalf("expected the explicit server to be kept, got %+v", spec.Servers)
+	}
+}
+
+func TestServedSpecIsNotHTMLEscaped(t *testing.T) {
+	r := router.New()
+	r.GET("/search", func(c *router.Context) {},
+		docs.WithDescription("Search by <name> & tag"),
+		docs.WithQueryParam("q", "string", false, "Terms & filters", nil),
+	)
+	if err := integration.Setup(r, integration.DefaultSetupOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	body := w.Body.String()
+	if strings.Contains(body, `\u0026`) || strings.Contains(body, `\u003c`) {
+		t.Fatalf("expected the served spec not to be HTML escaped, got %s", body)
+	}
+	for _, text := range []string{"Search by <name> & tag", "Terms & filters"} {
+		if !strings.Contains(body, text) {
+			t.Fatalf("expected %q in the served spec, got %s", text, body)
+		}
+	}
+}
+
+func TestAdapterSpec(t *testing.T) {
+	r := router.New()
+	r.GET("/users", func(c *router.Context) {})
+	r.POST("/users", func(c *router.Context) {})
+	r.GET("/internal", func(c *router.Context) {}, docs.WithExcludeFromDocs())
+
+	adapter := integration.NewRouterOpenAPIAdapter(r, openapi.NewGenerator(openapi.Info{Title: "Test API", Version: "1.0.0"}))
+	spec := adapter.Spec()
+
+	if len(spec.Paths) != 1 {
+		t.Fatalf("expected only /users to be documented, got %v", spec.Paths)
+	}
+	users, ok := spec.Paths["/users"]
+	if !ok || users.Get == nil || users.Post == nil {
+		t.Fatalf("expected GET and POST /users, got %+v", spec.Paths)
+	}
+}
+
+func TestSetupServersAndOAuth2(t *testing.T) {
+	r := router.New()
+	opts := integration.DefaultSetupOptions()
+	opts.Servers = []integration.ServerSpec{
+		{URL: "https://api.example.com", Description: "Production"},
+		{URL: "https://staging.example.com", Description: "Staging"},
+	}
+	opts.OAuth2 = &integration.OAuth2Setup{
+		AuthorizationURL: "https://auth.example.com/authorize",
+		TokenURL:         "https://auth.example.com/token",
+		Scopes:           map[string]string{"todos:read": "Read todos"},
+		ClientID:         "docs-client",
+		UsePKCE:          true,
+	}
+	if err := integration.Setup(r, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := fetchSpec(t, r, "/openapi.json")
+	if len(spec.Servers) != 2 || spec.Servers[0].URL != "https://api.example.com" || spec.Servers[1].Description != "Staging" {
+		t.Fatalf("expected the configured servers, got %+v", spec.Servers)
+	}
+	scheme, ok := spec.Components.SecuritySchemes["oauth2"]
+	if !ok || scheme.Type != "oauth2" || scheme.Flows == nil || scheme.Flows.AuthorizationCode == nil {
+		t.Fatalf("expected an oauth2 authorization code scheme, got %+v", spec.Components.SecuritySchemes)
+	}
+	if scheme.Flows.AuthorizationCode.TokenURL != "https://auth.example.com/token" {
+		t.Fatalf("expected the configured token URL, got %+v", scheme.Flows.AuthorizationCode)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+	if body := w.Body.String(); !strings.Contains(body, `clientId: "docs-client"`) {
+		t.Fatalf("expected Swagger UI to be configured for OAuth2, got %s", body)
+	}
+}
+
+func TestSetupRejectsUnknownOAuth2Flow(t *testing.T) {
+	opts := integration.DefaultSetupOptions()
+	opts.OAuth2 = &integration.OAuth2Setup{Flow: "device"}
+	if err := integration.Setup(router.New(), opts); err == nil {
+		t.Fatal("expected an error for an unsupported OAuth2 flow")
+	}
+}
+
+func TestSetupWithGeneratorReturnsServedGenerator(t *testing.T) {
+	r := router.New()
+	generator, err := integration.SetupWithGenerator(r, integration.DefaultSetupOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator.WithServer("https://api.example.com", "Production")
+	generator.WithBearerAuth("bearerAuth", "Bearer token authentication")
+
+	spec := fetchSpec(t, r, "/openapi.json")
+	if len(spec.Servers) != 1 || spec.Servers[0].URL != "https://api.example.com" {
+		t.Fatalf("expected the server added after setup, got %+v", spec.Servers)
+	}
+	if _, ok := spec.Components.SecuritySchemes["bearerAuth"]; !ok {
+		t.Fatalf("expected the scheme added after setup, got %+v", spec.Components.SecuritySchemes)
+	}
+}
+
+func TestServeIndex(t *testing.T) {
+	r := router.New()
+	r.GET("/users", func(c *router.Context) {}, docs.WithSummary("List users"), docs.WithTags("users"))
+	r.POST("/users", func(c *router.Context) {}, docs.WithSummary("Create a user"), docs.WithTags("users"))
+	r.GET("/health", func(c *router.Context) {}, docs.WithSummary("Check health"))
+	adapter := integration.NewRouterOpenAPIAdapter(r, openapi.NewGenerator(openapi.Info{Title: "Test API", Version: "1.0.0"}))
+
+	w := httptest.NewRecorder()
+	adapter.ServeIndex(w, httptest.NewRequest("GET", "/index", nil))
+	body := w.Body.String()
+	for _, text := range []string{"<title>Test API</title>", "<h2>users</h2>", "List users", "Create a user", "Check health"} {
+		if !strings.Contains(body, text) {
+			t.Fatalf("expected %q in the index page, got %s", text, body)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/index", nil)
+	req.Header.Set("Accept", "application/json")
+	w = httptest.NewRecorder()
+	adapter.ServeIndex(w, req)
+
+	var index []integration.IndexGroup
+	if err := json.Unmarshal(w.Body.Bytes(), &index); err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != 2 || index[0].Tag != "users" || index[1].Tag != "default" {
+		t.Fatalf("expected the users group followed by the default group, got %+v", index)
+	}
+	users := index[0].Operations
+	if len(users) != 2 || users[0].Method != "GET" || users[0].Summary != "List users" || users[1].Summary != "Create a user" {
+		t.Fatalf("expected the users operations with their summaries, got %+v", users)
+	}
+}
+
+func TestDisabledRoutesAreNotServedOrDocumented(t *testing.T) {
+	r := router.New()
+	r.GET("/users", func(c *router.Context) {})
+	r.GET("/exports", func(c *router.Context) {}, docs.WithEnabled(false))
+	r.GroupIf(false, "/beta", func(beta *router.Router) {
+		beta.GET("/reports", func(c *router.Context) {})
+	})
+	r.GroupIf(true, "/v1", func(v1 *router.Router) {
+		v1.GET("/reports", func(c *router.Context) {})
+	})
+
+	for path, want := range map[string]int{"/users": 200, "/v1/reports": 200, "/exports": 404, "/beta/reports": 404} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != want {
+			t.Errorf("GET %s: expected %d, got %d", path, want, w.Code)
+		}
+	}
+
+	spec := integration.NewRouterOpenAPIAdapter(r, openapi.NewGenerator(openapi.Info{Title: "Test API", Version: "1.0.0"})).Spec()
+	if len(spec.Paths) != 2 {
+		t.Fatalf("expected only /users and /v1/reports to be documented, got %v", spec.Paths)
+	}
+	if _, ok := spec.Paths["/beta/reports"]; ok {
+		t.Error("expected the disabled group to be absent from the spec")
+	}
+}
@@ -2,6 +2,7 @@ package integration
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/joakimcarlsson/go-router/openapi"
 	"github.com/joakimcarlsson/go-router/router"
@@ -15,6 +16,15 @@ type RouterOpenAPIAdapter struct {
 	Router *router.Router
 	// Generator is the OpenAPI generator used to create the specification
 	Generator *openapi.Generator
+	// ServersFromRequest derives the servers of the served specification from
+	// the incoming request when the generator has no explicit servers, so the
+	// spec stays correct behind a proxy
+	ServersFromRequest bool
+	// TrustForwardedHeaders takes the scheme and host of servers derived from
+	// the request from the X-Forwarded-Proto and X-Forwarded-Host headers.
+	// Enable it only behind a proxy that sets them, since clients can
+	// otherwise choose the URLs listed in the spec
+	TrustForwardedHeaders bool
 }
 
 // NewRouterOpenAPIAdapter creates a new adapter.
@@ -32,14 +42,14 @@ func NewRouterOpenAPIAdapter(r *router.Router, generator *openapi.Generator) *Ro
 
 // ExtractRouteInfo extracts OpenAPI route information from the router.
 // It converts the router's route metadata to the format expected by
-// the OpenAPI generator.
+// the OpenAPI generator. Routes marked with docs.WithExcludeFromDocs are skipped.
 func (a *RouterOpenAPIAdapter) ExtractRouteInfo() []openapi.RouteInfo {
 	routes := a.Router.Routes()
 	routeInfos := make([]openapi.RouteInfo, 0, len(routes))
 
 	for _, route := range routes {
 		// Convert RouteMetadata to RouteInfo
-		if route.Metadata != nil {
+		if route.Metadata != nil && !route.Metadata.ExcludeFromDocs {
 			routeInfos = append(routeInfos, openapi.RouteInfoFromMetadata(*route.Metadata))
 		}
 	}
@@ -47,22 +57,62 @@ func (a *RouterOpenAPIAdapter) ExtractRouteInfo() []openapi.RouteInfo {
 	return routeInfos
 }
 
+// Spec returns the OpenAPI specification of the router's routes, the
+// document ServeHTTP serves, without writing it anywhere. Use it for golden
+// file tests of the spec or to post-process and serialize it yourself.
+// Servers derived from a request by ServersFromRequest are not included.
+func (a *RouterOpenAPIAdapter) Spec() *openapi.Spec {
+	return a.Generator.Generate(a.ExtractRouteInfo())
+}
+
 // GenerateOpenAPISpec generates an OpenAPI specification from the router's routes.
-// This creates a complete OpenAPI specification document based on the
-// route metadata and configuration in the generator.
+// It is equivalent to Spec.
 func (a *RouterOpenAPIAdapter) GenerateOpenAPISpec() *openapi.Spec {
-	routeInfos := a.ExtractRouteInfo()
-	return a.Generator.Generate(routeInfos)
+	return a.Spec()
 }
 
 // ServeHTTP implements http.Handler interface.
 // This allows the adapter to be used as an HTTP handler to serve
 // the OpenAPI specification as JSON.
 func (a *RouterOpenAPIAdapter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	spec := a.GenerateOpenAPISpec()
+	spec := a.Spec()
+	if a.ServersFromRequest && len(spec.Servers) == 0 {
+		spec.Servers = []openapi.Server{{URL: requestBaseURL(r, a.TrustForwardedHeaders)}}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := openapi.WriteJSON(w, spec); err != nil {
 		http.Error(w, "Failed to write OpenAPI spec", http.StatusInternalServerError)
 	}
 }
+
+// requestBaseURL returns the URL the client used to reach the server.
+// When trustForwarded is set, the scheme is taken from X-Forwarded-Proto,
+// if it is http or https, and the host from X-Forwarded-Host, falling back
+// to the request itself.
+func requestBaseURL(r *http.Request, trustForwarded bool) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host := r.Host
+	if !trustForwarded {
+		return scheme + "://" + host
+	}
+
+	switch proto := strings.ToLower(forwardedValue(r.Header.Get("X-Forwarded-Proto"))); proto {
+	case "http", "https":
+		scheme = proto
+	}
+	if forwarded := forwardedValue(r.Header.Get("X-Forwarded-Host")); forwarded != "" {
+		host = forwarded
+	}
+	return scheme + "://" + host
+}
+
+// forwardedValue returns the first value of a forwarded header, the one set
+// by the proxy closest to the client
+func forwardedValue(header string) string {
+	value, _, _ := strings.Cut(header, ",")
+	return strings.TrimSpace(value)
+}
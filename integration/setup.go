@@ -2,7 +2,9 @@ package integration
 
 import (
 	"fmt"
+	"sort"
 
+	"github.com/joakimcarlsson/go-router/metadata"
 	"github.com/joakimcarlsson/go-router/openapi"
 	"github.com/joakimcarlsson/go-router/router"
 	"github.com/joakimcarlsson/go-router/swagger"
@@ -20,6 +22,15 @@ type SetupOptions struct {
 	SpecPath string // Path to serve OpenAPI JSON (default: /openapi.json)
 	DocsPath string // Path to serve Swagger UI (default: /docs)
 
+	// SpecCORSOrigin allows browsers on this origin to fetch the spec ("*" for any)
+	SpecCORSOrigin string
+
+	// ServersFromRequest derives the spec's servers from the incoming request
+	ServersFromRequest bool
+	// TrustForwardedHeaders uses the X-Forwarded-Proto and X-Forwarded-Host
+	// headers for those servers; enable it only behind a proxy that sets them
+	TrustForwardedHeaders bool
+
 	// UI customization
 	DarkMode bool   // Enable dark mode in Swagger UI
 	UITitle  string // Custom title for Swagger UI page (defaults to Title if not set)
@@ -28,6 +39,41 @@ type SetupOptions struct {
 	UseBasicAuth  bool // Add basic auth security scheme
 	UseBearerAuth bool // Add bearer token security scheme
 	UseAPIKey     bool // Add API key security scheme
+
+	// Servers lists the URLs the API is available at
+	Servers []ServerSpec
+	// OAuth2 adds an "oauth2" security scheme, the one docs.WithOAuth2Scopes
+	// refers to, and lets users authorize from Swagger UI
+	OAuth2 *OAuth2Setup
+}
+
+// ServerSpec describes a server the API is available at.
+type ServerSpec struct {
+	URL         string // Base URL of the API, e.g. https://api.example.com/v1
+	Description string // Optional description, e.g. "Production"
+}
+
+// OAuth2 flows supported by OAuth2Setup.
+const (
+	OAuth2AuthorizationCode = "authorizationCode"
+	OAuth2Implicit          = "implicit"
+	OAuth2Password          = "password"
+	OAuth2ClientCredentials = "clientCredentials"
+)
+
+// OAuth2Setup configures the OAuth2 security scheme of the API and the
+// authorization dialog of Swagger UI.
+type OAuth2Setup struct {
+	Flow             string            // One of the OAuth2 flow constants (default: OAuth2AuthorizationCode)
+	Description      string            // Description of the security scheme
+	AuthorizationURL string            // Authorization endpoint, used by the authorization code and implicit flows
+	TokenURL         string            // Token endpoint, used by all flows but implicit
+	Scopes           map[string]string // Available scopes and their descriptions
+
+	// Swagger UI client settings
+	ClientID string // Client ID prefilled in the authorization dialog
+	AppName  string // Application name shown to the authorization server
+	UsePKCE  bool   // Use PKCE with the authorization code flow
 }
 
 // DefaultSetupOptions returns default setup options for API documentation.
@@ -54,6 +100,23 @@ func DefaultSetupOptions() SetupOptions {
 //	    log.Fatal(err)
 //	}
 func Setup(r *router.Router, opts SetupOptions) error {
+	_, err := SetupWithGenerator(r, opts)
+	return err
+}
+
+// SetupWithGenerator is like Setup but also returns the OpenAPI generator
+// backing the served specification. The specification is generated on each
+// request, so security schemes, servers and other settings added to the
+// generator afterwards are included.
+//
+// Example:
+//
+//	generator, err := integration.SetupWithGenerator(router, integration.DefaultSetupOptions())
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	generator.WithOpenIDConnect("oidc", "OpenID Connect", "https://auth.example.com/.well-known/openid-configuration")
+func SetupWithGenerator(r *router.Router, opts SetupOptions) (*openapi.Generator, error) {
 	// Validate paths to ensure we don't have duplicate routes
 	if opts.SpecPath == "" {
 		opts.SpecPath = "/openapi.json"
@@ -64,7 +127,7 @@ func Setup(r *router.Router, opts SetupOptions) error {
 
 	// Check for path conflicts
 	if opts.SpecPath == opts.DocsPath {
-		return fmt.Errorf("spec path and docs path cannot be the same: %s", opts.SpecPath)
+		return nil, fmt.Errorf("spec path and docs path cannot be the same: %s", opts.SpecPath)
 	}
 
 	// Create OpenAPI generator
@@ -84,6 +147,14 @@ func Setup(r *router.Router, opts SetupOptions) error {
 	if opts.UseAPIKey {
 		generator.WithAPIKey("apiKey", "API key authentication", "header", "X-API-Key")
 	}
+	for _, server := range opts.Servers {
+		generator.WithServer(server.URL, server.Description)
+	}
+	if opts.OAuth2 != nil {
+		if err := setupOAuth2(generator, opts.OAuth2); err != nil {
+			return nil, err
+		}
+	}
 
 	// Configure Swagger UI
 	uiConfig := swagger.DefaultUIConfig()
@@ -93,13 +164,57 @@ func Setup(r *router.Router, opts SetupOptions) error {
 		uiConfig.Title = opts.Title
 	}
 	uiConfig.DarkMode = opts.DarkMode
+	if opts.OAuth2 != nil {
+		uiConfig.OAuth2Config = oauth2UIConfig(opts.OAuth2)
+	}
 
 	// Set up the integration
 	swaggerUI := NewSwaggerUIIntegration(r, generator)
 	swaggerUI.WithUIConfig(uiConfig)
+	swaggerUI.WithSpecCORS(opts.SpecCORSOrigin)
+	swaggerUI.WithServersFromRequest(opts.ServersFromRequest)
+	swaggerUI.WithTrustForwardedHeaders(opts.TrustForwardedHeaders)
 
 	// Set up routes with provided paths
 	swaggerUI.SetupRoutes(r, opts.SpecPath, opts.DocsPath)
 
+	return generator, nil
+}
+
+// setupOAuth2 adds the "oauth2" security scheme for the configured flow.
+func setupOAuth2(generator *openapi.Generator, oauth *OAuth2Setup) error {
+	description := oauth.Description
+	if description == "" {
+		description = "OAuth2 authentication"
+	}
+
+	switch oauth.Flow {
+	case "", OAuth2AuthorizationCode:
+		generator.WithOAuth2AuthorizationCodeFlow("oauth2", description, oauth.AuthorizationURL, oauth.TokenURL, oauth.Scopes)
+	case OAuth2Implicit:
+		generator.WithOAuth2ImplicitFlow("oauth2", description, oauth.AuthorizationURL, oauth.Scopes)
+	case OAuth2Password:
+		generator.WithOAuth2PasswordFlow("oauth2", description, oauth.TokenURL, oauth.Scopes)
+	case OAuth2ClientCredentials:
+		generator.WithOAuth2ClientCredentialsFlow("oauth2", description, oauth.TokenURL, oauth.Scopes)
+	default:
+		return fmt.Errorf("unsupported OAuth2 flow: %s", oauth.Flow)
+	}
 	return nil
 }
+
+// oauth2UIConfig returns the Swagger UI OAuth2 configuration, preselecting
+// every scope of the scheme.
+func oauth2UIConfig(oauth *OAuth2Setup) *metadata.OAuth2Config {
+	scopes := make([]string, 0, len(oauth.Scopes))
+	for scope := range oauth.Scopes {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+
+	return metadata.NewOAuth2Config().
+		WithClientID(oauth.ClientID).
+		WithAppName(oauth.AppName).
+		WithScopes(scopes...).
+		WithPKCE(oauth.UsePKCE)
+}
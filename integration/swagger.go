@@ -3,6 +3,7 @@ package integration
 import (
 	"net/http"
 
+	"github.com/joakimcarlsson/go-router/docs"
 	"github.com/joakimcarlsson/go-router/openapi"
 	"github.com/joakimcarlsson/go-router/router"
 	"github.com/joakimcarlsson/go-router/swagger"
@@ -16,6 +17,9 @@ type SwaggerUIIntegration struct {
 	OpenAPIAdapter *RouterOpenAPIAdapter
 	// UIConfig contains configuration for the Swagger UI
 	UIConfig swagger.UIConfig
+	// SpecCORSOrigin is sent as Access-Control-Allow-Origin on the spec route
+	// when set, allowing external tools such as Swagger Editor to fetch the spec
+	SpecCORSOrigin string
 }
 
 // NewSwaggerUIIntegration creates a new Swagger UI integration.
@@ -43,7 +47,40 @@ func (s *SwaggerUIIntegration) WithUIConfig(config swagger.UIConfig) *SwaggerUII
 	return s
 }
 
+// WithSpecCORS allows browsers on the given origin to fetch the OpenAPI specification.
+// Use "*" to allow any origin, e.g. for the hosted Swagger Editor.
+// Only the spec route is affected.
+//
+// Returns the SwaggerUIIntegration for method chaining.
+func (s *SwaggerUIIntegration) WithSpecCORS(origin string) *SwaggerUIIntegration {
+	s.SpecCORSOrigin = origin
+	return s
+}
+
+// WithServersFromRequest derives the servers of the served specification from
+// the incoming request when the generator has no explicit servers.
+// The scheme and host are those of the request, or taken from the forwarded
+// headers when WithTrustForwardedHeaders is enabled.
+//
+// Returns the SwaggerUIIntegration for method chaining.
+func (s *SwaggerUIIntegration) WithServersFromRequest(enabled bool) *SwaggerUIIntegration {
+	s.OpenAPIAdapter.ServersFromRequest = enabled
+	return s
+}
+
+// WithTrustForwardedHeaders takes the scheme and host of servers derived from
+// the request from the X-Forwarded-Proto and X-Forwarded-Host headers.
+// Enable it only when the API is served behind a proxy that sets them.
+//
+// Returns the SwaggerUIIntegration for method chaining.
+func (s *SwaggerUIIntegration) WithTrustForwardedHeaders(enabled bool) *SwaggerUIIntegration {
+	s.OpenAPIAdapter.TrustForwardedHeaders = enabled
+	return s
+}
+
 // SetupRoutes sets up the OpenAPI JSON and Swagger UI routes on the router.
+// Both routes are excluded from the generated documentation.
+// It panics if a custom UI template is configured that does not parse.
 // This registers two routes:
 //  1. A route to serve the OpenAPI JSON specification
 //  2. A route to serve the Swagger UI that consumes the specification
@@ -54,13 +91,21 @@ func (s *SwaggerUIIntegration) WithUIConfig(config swagger.UIConfig) *SwaggerUII
 //   - uiPath: The path to serve the Swagger UI (e.g., "/docs")
 func (s *SwaggerUIIntegration) SetupRoutes(r *router.Router, specPath, uiPath string) {
 	// Serve OpenAPI JSON
-	r.GET(specPath, wrapHandler(s.OpenAPIAdapter.ServeHTTP))
+	specHandler := wrapHandler(s.OpenAPIAdapter.ServeHTTP)
+	if origin := s.SpecCORSOrigin; origin != "" {
+		next := specHandler
+		specHandler = func(c *router.Context) {
+			c.SetHeader("Access-Control-Allow-Origin", origin)
+			next(c)
+		}
+	}
+	r.GET(specPath, specHandler, docs.WithExcludeFromDocs())
 
 	// Configure UI to use the correct spec path
 	s.UIConfig.SpecURL = specPath
 
 	// Serve Swagger UI
-	r.GET(uiPath, wrapHandler(swagger.Handler(s.UIConfig)))
+	r.GET(uiPath, wrapHandler(swagger.Handler(s.UIConfig)), docs.WithExcludeFromDocs())
 }
 
 // wrapHandler converts an http.HandlerFunc to a router.HandlerFunc.
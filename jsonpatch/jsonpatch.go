@@ -0,0 +1,373 @@
+// Package jsonpatch implements JSON Patch (RFC 6902), a format describing
+// changes to a JSON document as a list of operations:
+//
+//	[
+//	  {"op": "replace", "path": "/title", "value": "Buy milk"},
+//	  {"op": "remove", "path": "/tags/0"}
+//	]
+//
+// Patches are sent with the application/json-patch+json content type. The
+// router's Context.BindJSONPatch6902 decodes one from a request body and
+// Patch.Apply applies it to a JSON document.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the media type of JSON Patch documents.
+const ContentType = "application/json-patch+json"
+
+// Errors returned, wrapped with the failing operation, by Decode and Apply.
+var (
+	// ErrInvalidOperation is returned for an operation that is malformed,
+	// such as an unknown op or a missing value
+	ErrInvalidOperation = errors.New("invalid operation")
+	// ErrInvalidPath is returned when a path or from pointer is malformed or
+	// does not refer to a location in the document
+	ErrInvalidPath = errors.New("invalid path")
+	// ErrTestFailed is returned when the value of a test operation does not
+	// match the document
+	ErrTestFailed = errors.New("test failed")
+)
+
+// Operation is a single JSON Patch operation.
+type Operation struct {
+	// Op is one of "add", "remove", "replace", "move", "copy" or "test"
+	Op string `json:"op"`
+	// Path is the JSON Pointer (RFC 6901) of the location to change
+	Path string `json:"path"`
+	// From is the JSON Pointer of the source location of move and copy,
+	// which can't be the whole document
+	From string `json:"from,omitempty"`
+	// Value is the value of add, replace and test
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch is a JSON Patch document, applied in order.
+type Patch []Operation
+
+// Decode parses a JSON Patch document and checks that its operations are
+// well-formed.
+func Decode(data []byte) (Patch, error) {
+	var patch Patch
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, err
+	}
+	for i, op := range patch {
+		if err := op.validate(); err != nil {
+			return nil, fmt.Errorf("jsonpatch: operation %d: %w", i, err)
+		}
+	}
+	return patch, nil
+}
+
+// validate checks that the operation has the members its op requires.
+func (op Operation) validate() error {
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return fmt.Errorf("%w: %s requires a value", ErrInvalidOperation, op.Op)
+		}
+	case "move", "copy":
+		if op.From == "" {
+			return fmt.Errorf("%w: %s requires a from location", ErrInvalidOperation, op.Op)
+		}
+		if _, err := parsePointer(op.From); err != nil {
+			return err
+		}
+	case "remove":
+	default:
+		return fmt.Errorf("%w: unknown op %q", ErrInvalidOperation, op.Op)
+	}
+	_, err := parsePointer(op.Path)
+	return err
+}
+
+// Apply applies the patch to a JSON document and returns the patched
+// document. The patch is applied as a whole: if an operation fails, an
+// error naming it is returned and doc is left as is.
+func (p Patch) Apply(doc []byte) ([]byte, error) {
+	var document interface{}
+	if err := decode(doc, &document); err != nil {
+		return nil, err
+	}
+
+	for i, op := range p {
+		var err error
+		if document, err = op.apply(document); err != nil {
+			return nil, fmt.Errorf("jsonpatch: operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(document)
+}
+
+// apply applies the operation to a decoded document.
+func (op Operation) apply(doc interface{}) (interface{}, error) {
+	if err := op.validate(); err != nil {
+		return nil, err
+	}
+	path, _ := parsePointer(op.Path)
+
+	switch op.Op {
+	case "add", "replace", "test":
+		var value interface{}
+		if err := decode(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidOperation, err)
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if _, err := get(doc, path); err != nil {
+				return nil, err
+			}
+			doc, _, err := remove(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !equal(current, value) {
+				return nil, ErrTestFailed
+			}
+			return doc, nil
+		}
+	case "remove":
+		doc, _, err := remove(doc, path)
+		return doc, err
+	case "move":
+		from, _ := parsePointer(op.From)
+		if op.Path != op.From && strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("%w: cannot move %s into itself", ErrInvalidPath, op.From)
+		}
+		doc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	default: // copy
+		from, _ := parsePointer(op.From)
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(value))
+	}
+}
+
+// decode decodes JSON keeping numbers as json.Number, so they round trip
+// without losing precision.
+func decode(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens.
+// The empty pointer refers to the whole document and has no tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("%w: %q does not start with /", ErrInvalidPath, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses an array index token referring to an existing element,
+// or to the end of the array when end is set.
+func arrayIndex(token string, length int, end bool) (int, error) {
+	if end && token == "-" {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') || token[0] == '+' {
+		return 0, fmt.Errorf("%w: %q is not an array index", ErrInvalidPath, token)
+	}
+	if i > length || (i == length && !end) {
+		return 0, fmt.Errorf("%w: index %d out of range", ErrInvalidPath, i)
+	}
+	return i, nil
+}
+
+// get returns the value the tokens refer to.
+func get(doc interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %q not found", ErrInvalidPath, token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("%w: %q is not in an object or array", ErrInvalidPath, token)
+		}
+	}
+	return doc, nil
+}
+
+// modify replaces the parent of the location the tokens refer to with the
+// result of fn, and returns the updated document.
+func modify(doc interface{}, tokens []string, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+
+	child, err := get(doc, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+	updated, err := modify(child, tokens[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		node[tokens[0]] = updated
+	case []interface{}:
+		i, _ := arrayIndex(tokens[0], len(node), false)
+		node[i] = updated
+	}
+	return doc, nil
+}
+
+// add adds value at the location the tokens refer to: it sets an object
+// member or inserts an array element, "-" appending to the array.
+func add(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return modify(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		default:
+			return nil, fmt.Errorf("%w: %q is not in an object or array", ErrInvalidPath, token)
+		}
+	})
+}
+
+// remove removes the value at the location the tokens refer to and returns
+// the updated document and the removed value.
+func remove(doc interface{}, tokens []string) (interface{}, interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+	var removed interface{}
+	doc, err := modify(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %q not found", ErrInvalidPath, token)
+			}
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[i]
+			return append(node[:i], node[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("%w: %q is not in an object or array", ErrInvalidPath, token)
+		}
+	})
+	return doc, removed, err
+}
+
+// deepCopy copies the objects and arrays of a decoded value.
+func deepCopy(value interface{}) interface{} {
+	switch node := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(node))
+		for k, v := range node {
+			copied[k] = deepCopy(v)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(node))
+		for i, v := range node {
+			copied[i] = deepCopy(v)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+// equal reports whether two decoded values are equal as defined for the
+// test operation: numbers are compared by value and objects regardless of
+// member order.
+func equal(a, b interface{}) bool {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			other, ok := b[k]
+			if !ok || !equal(v, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, okA := new(big.Float).SetString(a.String())
+		y, okB := new(big.Float).SetString(b.String())
+		return okA && okB && x.Cmp(y) == 0
+	default:
+		return a == b
+	}
+}
@@ -0,0 +1,129 @@
+package jsonpatch_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/joakimcarlsson/go-router/jsonpatch"
+)
+
+const document = `{"title":"Groceries","tags":["home","urgent"],"owner":{"name":"Ada","id":7}}`
+
+// jsonEqual reports whether two JSON documents hold the same value.
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var x, y interface{}
+	if err := json.Unmarshal(a, &x); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		t.Fatal(err)
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{
+			"add member",
+			`[{"op":"add","path":"/done","value":false}]`,
+			`{"title":"Groceries","tags":["home","urgent"],"owner":{"name":"Ada","id":7},"done":false}`,
+		},
+		{
+			"add array element",
+			`[{"op":"add","path":"/tags/1","value":"shared"},{"op":"add","path":"/tags/-","value":"later"}]`,
+			`{"title":"Groceries","tags":["home","shared","urgent","later"],"owner":{"name":"Ada","id":7}}`,
+		},
+		{
+			"remove",
+			`[{"op":"remove","path":"/tags/0"},{"op":"remove","path":"/owner/id"}]`,
+			`{"title":"Groceries","tags":["urgent"],"owner":{"name":"Ada"}}`,
+		},
+		{
+			"replace",
+			`[{"op":"replace","path":"/title","value":"Errands"},{"op":"replace","path":"/tags/1","value":"later"}]`,
+			`{"title":"Errands","tags":["home","later"],"owner":{"name":"Ada","id":7}}`,
+		},
+		{
+			"move",
+			`[{"op":"move","from":"/owner/name","path":"/assignee"}]`,
+			`{"title":"Groceries","tags":["home","urgent"],"owner":{"id":7},"assignee":"Ada"}`,
+		},
+		{
+			"copy",
+			`[{"op":"copy","from":"/owner","path":"/reviewer"},{"op":"replace","path":"/reviewer/name","value":"Grace"}]`,
+			`{"title":"Groceries","tags":["home","urgent"],"owner":{"name":"Ada","id":7},"reviewer":{"name":"Grace","id":7}}`,
+		},
+		{
+			"test",
+			`[{"op":"test","path":"/owner","value":{"id":7.0,"name":"Ada"}},{"op":"remove","path":"/owner"}]`,
+			`{"title":"Groceries","tags":["home","urgent"]}`,
+		},
+		{
+			"escaped pointer",
+			`[{"op":"add","path":"/a~1b~0c","value":1}]`,
+			`{"title":"Groceries","tags":["home","urgent"],"owner":{"name":"Ada","id":7},"a/b~c":1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := jsonpatch.Decode([]byte(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := patch.Apply([]byte(document))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		err   error
+	}{
+		{"missing member", `[{"op":"remove","path":"/missing"}]`, jsonpatch.ErrInvalidPath},
+		{"index out of range", `[{"op":"replace","path":"/tags/2","value":"x"}]`, jsonpatch.ErrInvalidPath},
+		{"path into a string", `[{"op":"add","path":"/title/x","value":"x"}]`, jsonpatch.ErrInvalidPath},
+		{"move into itself", `[{"op":"move","from":"/owner","path":"/owner/copy"}]`, jsonpatch.ErrInvalidPath},
+		{"failed test", `[{"op":"test","path":"/title","value":"Errands"}]`, jsonpatch.ErrTestFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := jsonpatch.Decode([]byte(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := patch.Apply([]byte(document)); !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsMalformedOperations(t *testing.T) {
+	for _, patch := range []string{
+		`[{"op":"rename","path":"/title"}]`,
+		`[{"op":"add","path":"/title"}]`,
+		`[{"op":"remove","path":"title"}]`,
+		`[{"op":"copy","path":"/title"}]`,
+	} {
+		if _, err := jsonpatch.Decode([]byte(patch)); err == nil {
+			t.Fatalf("expected %s to be rejected", patch)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package metadata
+
+import "strings"
+
+// PathParamNames returns the names of the {name} segments in a path pattern,
+// such as "id" for "/users/{id}". Wildcard suffixes ("{name...}") are
+// stripped and the "{$}" anchor is ignored.
+func PathParamNames(path string) []string {
+	var names []string
+	for {
+		start := strings.Index(path, "{")
+		if start == -1 {
+			return names
+		}
+		end := strings.Index(path[start:], "}")
+		if end == -1 {
+			return names
+		}
+		name := strings.TrimSuffix(path[start+1:start+end], "...")
+		if name != "" && name != "$" {
+			names = append(names, name)
+		}
+		path = path[start+end+1:]
+	}
+}
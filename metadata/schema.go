@@ -0,0 +1,402 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Standard library types with a dedicated schema
+var (
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+	urlType        = reflect.TypeOf(url.URL{})
+)
+
+// SchemaFromType generates a Schema from a Go type. It is the schema
+// generator behind docs.SchemaFromType and openapi.SchemaFromType.
+//
+// Structs become object schemas with a property per exported field, named
+// after its json tag. Fields are further described by struct tags:
+// validate for required fields and min, max and unique constraints, title
+// and description, example, and openapi:"deprecated". Pointers are
+// nullable. Struct schemas carry their Go type, from which each OpenAPI
+// generator assigns collision-free component names.
+func SchemaFromType(t reflect.Type) Schema {
+	// Special handling for standard library types
+	switch {
+	case t.String() == "time.Time":
+		return Schema{
+			Type:     "string",
+			Format:   "date-time",
+			Example:  "2025-02-22T08:36:06.224266+01:00",
+			TypeName: "time.Time",
+		}
+	case t == durationType:
+		return Schema{
+			Type:        "integer",
+			Format:      "int64",
+			Description: "Duration in nanoseconds",
+			Example:     int64(time.Second),
+			TypeName:    "time.Duration",
+		}
+	case t == urlType:
+		return Schema{
+			Type:     "string",
+			Format:   "uri",
+			Example:  "https://example.com",
+			TypeName: "url.URL",
+		}
+	case t == rawMessageType:
+		// Raw JSON can be any value, so the schema has no type
+		return Schema{
+			TypeName: "json.RawMessage",
+		}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return Schema{
+			Type:     "string",
+			Format:   "byte",
+			TypeName: "[]byte",
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := SchemaFromType(t.Elem())
+		schema.Nullable = true
+		return schema
+	case reflect.Struct:
+		properties, required := getStructProperties(t)
+
+		schema := Schema{
+			Type:       "object",
+			Properties: properties,
+			TypeName:   t.Name(),
+			GoType:     t,
+		}
+		schema.Title, schema.Description = structDocumentation(t)
+		if len(required) > 0 {
+			schema.Required = required
+		}
+		if example := generateExample(t); example != nil {
+			schema.Example = example
+		}
+		return schema
+	case reflect.Slice, reflect.Array:
+		itemSchema := SchemaFromType(t.Elem())
+		return Schema{
+			Type:     "array",
+			Items:    &itemSchema,
+			TypeName: "[]" + itemSchema.TypeName,
+		}
+	default:
+		schema := Schema{
+			Type:     getGoTypeSchema(t),
+			TypeName: t.Name(),
+		}
+		schema.Example = getExampleValue(t)
+		return schema
+	}
+}
+
+// structDocumentation returns the title and description of a struct type,
+// read from the tags of a blank field since types themselves carry no tags:
+//
+//	type Order struct {
+//		_  struct{} `title:"Order" description:"An order placed by a customer"`
+//		ID string   `json:"id"`
+//	}
+func structDocumentation(t reflect.Type) (title, description string) {
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.Name == "_" {
+			return field.Tag.Get("title"), field.Tag.Get("description")
+		}
+	}
+	return "", ""
+}
+
+// hasOpenAPIOption reports whether the openapi struct tag of a field lists
+// the option, as in openapi:"deprecated".
+func hasOpenAPIOption(field reflect.StructField, option string) bool {
+	for _, opt := range strings.Split(field.Tag.Get("openapi"), ",") {
+		if strings.TrimSpace(opt) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// getValidationRules reports whether a field is required and splits the
+// rest of its validate tag into the rules constraining the field itself and
+// the rules prefixed with "items.", which constrain each element of a slice
+// or array field: validate:"items.min=0,items.max=100" limits the elements
+// of a []int to 0 through 100.
+func getValidationRules(field reflect.StructField) (required bool, rules, itemRules []string) {
+	tag := field.Tag.Get("validate")
+	if tag == "" {
+		return
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		switch {
+		case rule == "required":
+			required = true
+		case strings.HasPrefix(rule, "items."):
+			itemRules = append(itemRules, strings.TrimPrefix(rule, "items."))
+		default:
+			rules = append(rules, rule)
+		}
+	}
+	return
+}
+
+// applyValidationRules sets the constraints of min= and max= rules on a
+// schema according to its type: the length of a string, the range of a
+// number or the number of items of an array. The unique rule requires the
+// items of an array to be unique. Rules that don't apply to the type are
+// ignored.
+func applyValidationRules(schema *Schema, rules []string) {
+	for _, rule := range rules {
+		if rule == "unique" && schema.Type == "array" {
+			schema.UniqueItems = true
+			continue
+		}
+
+		name, value, ok := strings.Cut(rule, "=")
+		if !ok || (name != "min" && name != "max") {
+			continue
+		}
+
+		switch schema.Type {
+		case "string":
+			if n, err := strconv.Atoi(value); err == nil {
+				if name == "min" {
+					schema.MinLength = &n
+				} else {
+					schema.MaxLength = &n
+				}
+			}
+		case "integer", "number":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				if name == "min" {
+					schema.Minimum = &n
+				} else {
+					schema.Maximum = &n
+				}
+			}
+		case "array":
+			if n, err := strconv.Atoi(value); err == nil {
+				if name == "min" {
+					schema.MinItems = &n
+				} else {
+					schema.MaxItems = &n
+				}
+			}
+		}
+	}
+}
+
+func getStructProperties(t reflect.Type) (map[string]Schema, []string) {
+	properties := make(map[string]Schema)
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Tag.Get("json")
+		if idx := strings.Index(name, ","); idx != -1 {
+			name = name[:idx]
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		isRequired, rules, itemRules := getValidationRules(field)
+		if isRequired {
+			required = append(required, name)
+		}
+
+		var schema Schema
+		if field.Type.Kind() == reflect.Ptr {
+			schema = SchemaFromType(field.Type.Elem())
+			schema.Nullable = true
+		} else {
+			schema = SchemaFromType(field.Type)
+		}
+		applyValidationRules(&schema, rules)
+		if schema.Items != nil {
+			applyValidationRules(schema.Items, itemRules)
+		}
+		if title := field.Tag.Get("title"); title != "" {
+			schema.Title = title
+		}
+		if desc := field.Tag.Get("description"); desc != "" {
+			schema.Description = desc
+		}
+		if hasOpenAPIOption(field, "deprecated") {
+			schema.Deprecated = true
+		}
+		if example, ok := exampleFromTag(t, field); ok {
+			schema.Example = example
+		}
+		properties[name] = schema
+	}
+
+	return properties, required
+}
+
+func getGoTypeSchema(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	default:
+		return "object"
+	}
+}
+
+func getExampleValue(t reflect.Type) interface{} {
+	switch t.Kind() {
+	case reflect.Bool:
+		return true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return 42
+	case reflect.Float32, reflect.Float64:
+		return 3.14
+	case reflect.String:
+		return "example"
+	default:
+		return nil
+	}
+}
+
+// exampleFromTag returns the example value set by the example tag of a
+// field, parsed according to the field's type: example:"42" is a number for
+// an int field and a string for a string field. Slices of basic types take a
+// comma-separated list, as in example:"red,green", and other types JSON.
+// It panics if the value doesn't parse, which is a mistake in the type, with
+// a message naming the field and its struct type owner.
+func exampleFromTag(owner reflect.Type, field reflect.StructField) (interface{}, bool) {
+	tag, ok := field.Tag.Lookup("example")
+	if !ok {
+		return nil, false
+	}
+
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	value, err := parseExample(t, tag)
+	if err != nil {
+		panic(fmt.Sprintf("metadata: invalid example tag %q on field %s.%s: %v", tag, owner, field.Name, err))
+	}
+	return value, true
+}
+
+// parseExample parses an example value for a type.
+func parseExample(t reflect.Type, s string) (interface{}, error) {
+	// Types documented as strings
+	if t.String() == "time.Time" || t == urlType || (t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8) {
+		return s, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(s, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(s, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(s, 64)
+	case reflect.String:
+		return s, nil
+	case reflect.Slice, reflect.Array:
+		if !strings.HasPrefix(strings.TrimSpace(s), "[") {
+			var values []interface{}
+			for _, item := range strings.Split(s, ",") {
+				value, err := parseExample(t.Elem(), strings.TrimSpace(item))
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, value)
+			}
+			return values, nil
+		}
+	}
+
+	var value interface{}
+	err := json.Unmarshal([]byte(s), &value)
+	return value, err
+}
+
+func generateExample(t reflect.Type) interface{} {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	example := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		// Skip unexported fields
+		if !field.IsExported() {
+			continue
+		}
+
+		// Get JSON tag name or field name
+		name := field.Tag.Get("json")
+		if idx := strings.Index(name, ","); idx != -1 {
+			name = name[:idx]
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		// Generate example value for the field, unless the example tag sets one
+		value, ok := exampleFromTag(t, field)
+		switch {
+		case ok:
+		case field.Type.Kind() == reflect.Struct:
+			if field.Type.String() == "time.Time" {
+				value = "2025-02-22T08:36:06.224266+01:00"
+			} else if field.Type == urlType {
+				value = "https://example.com"
+			} else {
+				value = generateExample(field.Type)
+			}
+		case field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Array:
+			if elemExample := generateExample(field.Type.Elem()); elemExample != nil {
+				value = []interface{}{elemExample}
+			}
+		default:
+			value = getExampleValue(field.Type)
+		}
+
+		if value != nil {
+			example[name] = value
+		}
+	}
+
+	return example
+}
@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 )
 
 // RouteMetadata contains documentation and configuration for a route.
@@ -21,11 +22,38 @@ type RouteMetadata struct {
 	Tags        []string `json:"tags,omitempty"`
 	Deprecated  bool     `json:"deprecated,omitempty"`
 
+	// Runtime behavior derived from documentation options
+	Sunset       time.Time `json:"-"` // Date after which the route is removed (RFC 8594)
+	CacheControl string    `json:"-"` // Cache-Control directive sent with every response
+	// MaxRequestBytes limits the size of request bodies, 0 means unlimited
+	MaxRequestBytes int64 `json:"-"`
+	// Annotations are free-form values read at runtime, e.g. by middleware
+	Annotations map[string]string `json:"-"`
+
+	// ExcludeFromDocs hides the route from generated documentation
+	ExcludeFromDocs bool `json:"-"`
+	// Disabled skips registering the route, for routes behind a feature flag
+	Disabled bool `json:"-"`
+	// NoTimeout exempts the route from the router's default timeout
+	NoTimeout bool `json:"-"`
+
 	// API Documentation (OpenAPI specific)
 	Parameters  []Parameter           `json:"parameters,omitempty"`
 	RequestBody *RequestBody          `json:"requestBody,omitempty"`
 	Responses   map[string]Response   `json:"responses"`
 	Security    []SecurityRequirement `json:"security,omitempty"`
+	// Servers overrides the API's servers for this operation
+	Servers []Server `json:"servers,omitempty"`
+
+	// Extensions holds vendor extensions (x-*) emitted on the operation
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// Server is a server an operation is served from, when it differs from the
+// servers of the API.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
 }
 
 // Parameter represents an API parameter such as path, query, header, or cookie parameters.
@@ -36,6 +64,11 @@ type Parameter struct {
 	Description string      `json:"description,omitempty"`
 	Schema      Schema      `json:"schema"`
 	Example     interface{} `json:"example,omitempty"`
+	// Examples holds named example values of the parameter
+	Examples map[string]interface{} `json:"examples,omitempty"`
+	// Ref names a reusable parameter component to reference instead of
+	// describing the parameter inline; the other fields are then ignored
+	Ref string `json:"-"`
 }
 
 // RequestBody represents a request body for an API operation.
@@ -44,6 +77,9 @@ type RequestBody struct {
 	Description string               `json:"description,omitempty"`
 	Required    bool                 `json:"required,omitempty"`
 	Content     map[string]MediaType `json:"content"`
+	// Ref names a reusable request body component to reference instead of
+	// describing the body inline; the other fields are then ignored
+	Ref string `json:"-"`
 }
 
 // Response represents an API response for an operation.
@@ -78,24 +114,49 @@ type Schema struct {
 	Type                 string            `json:"type,omitempty"`
 	Ref                  string            `json:"$ref,omitempty"`
 	Format               string            `json:"format,omitempty"`
+	Title                string            `json:"title,omitempty"`
 	Description          string            `json:"description,omitempty"`
 	Items                *Schema           `json:"items,omitempty"`
 	Properties           map[string]Schema `json:"properties,omitempty"`
 	Example              interface{}       `json:"example,omitempty"`
+	Default              interface{}       `json:"default,omitempty"`
 	Required             []string          `json:"required,omitempty"`
 	MinLength            *int              `json:"minLength,omitempty"`
 	MaxLength            *int              `json:"maxLength,omitempty"`
 	Minimum              *float64          `json:"minimum,omitempty"`
 	Maximum              *float64          `json:"maximum,omitempty"`
+	MinItems             *int              `json:"minItems,omitempty"`
+	MaxItems             *int              `json:"maxItems,omitempty"`
+	UniqueItems          bool              `json:"uniqueItems,omitempty"`
 	Enum                 []interface{}     `json:"enum,omitempty"`
 	AllOf                []Schema          `json:"allOf,omitempty"`
 	OneOf                []Schema          `json:"oneOf,omitempty"`
 	AnyOf                []Schema          `json:"anyOf,omitempty"`
 	Nullable             bool              `json:"nullable,omitempty"`
+	Deprecated           bool              `json:"deprecated,omitempty"`
 	AdditionalProperties *Schema           `json:"additionalProperties,omitempty"`
 	TypeName             string            `json:"-"`
+	GoType               reflect.Type      `json:"-"` // Go type the schema was generated from, if any
+	// Extensions holds vendor extensions (x-*) emitted on the schema
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// Page is the envelope of a page of a cursor paginated list of T, as
+// documented by docs.WithPagedJSONResponse. Its component schema is named
+// after the schema of T, such as "PagedUser".
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+}
+
+// IsPageType reports whether t is an instantiation of Page.
+func IsPageType(t reflect.Type) bool {
+	return t != nil && t.PkgPath() == pagePkgPath && strings.HasPrefix(t.Name(), "Page[")
 }
 
+var pagePkgPath = reflect.TypeOf(Page[struct{}]{}).PkgPath()
+
 // TypeRegistryEntry stores information about a registered type
 type TypeRegistryEntry struct {
 	Name      string
@@ -104,82 +165,96 @@ type TypeRegistryEntry struct {
 	FinalName string
 }
 
-// typeRegistry tracks registered types and detects name collisions
-type typeRegistry struct {
+// TypeRegistry tracks registered types and assigns them non-colliding schema names.
+// Each OpenAPI generator uses its own registry so that documenting several
+// APIs in one process doesn't cross-contaminate schema names.
+type TypeRegistry struct {
+	// types holds the entries keyed by package path and type name
 	types map[string]*TypeRegistryEntry
+	// names groups the entries sharing the same unqualified type name
+	names map[string][]*TypeRegistryEntry
 	mu    sync.RWMutex
 }
 
-// global registry instance
-var globalTypeRegistry *typeRegistry
-
-// init initializes the global type registry
-func init() {
-	globalTypeRegistry = &typeRegistry{
+// NewTypeRegistry creates an empty type registry.
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{
 		types: make(map[string]*TypeRegistryEntry),
+		names: make(map[string][]*TypeRegistryEntry),
 	}
 }
 
-// RegisterType adds a type to the registry and returns a non-colliding name
+// global registry instance used by RegisterType
+var globalTypeRegistry = NewTypeRegistry()
+
+// RegisterType adds a type to the process-wide registry and returns a non-colliding name.
+//
+// Deprecated: The library no longer uses the process-wide registry; each
+// OpenAPI generator names the types of its routes with its own TypeRegistry.
 func RegisterType(t reflect.Type) string {
+	return globalTypeRegistry.Register(t)
+}
+
+// ResetTypeRegistry clears the process-wide registry used by RegisterType.
+//
+// Deprecated: The library no longer uses the process-wide registry.
+func ResetTypeRegistry() {
 	globalTypeRegistry.mu.Lock()
 	defer globalTypeRegistry.mu.Unlock()
+	globalTypeRegistry.types = make(map[string]*TypeRegistryEntry)
+	globalTypeRegistry.names = make(map[string][]*TypeRegistryEntry)
+}
+
+// Register adds a type to the registry and returns a non-colliding name.
+// A type whose name is unique uses its plain name. As soon as two types from
+// different packages share a name, all of them use a name qualified with their
+// full package path, so the final names don't depend on registration order.
+// Registering a colliding type may therefore change the name of a previously
+// registered type; use Name to look up the current name.
+func (r *TypeRegistry) Register(t reflect.Type) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
 	name := t.Name()
 	pkgPath := t.PkgPath()
 	fullID := pkgPath + "." + name
 
 	// Check if we've seen this exact type before (same name and package)
-	if entry, exists := globalTypeRegistry.types[fullID]; exists {
+	if entry, exists := r.types[fullID]; exists {
 		entry.Count++
-		// Return the name we've already assigned to this type
 		return entry.FinalName
 	}
 
-	// Check if we've seen this base name before but with a different package
-	if entry, exists := globalTypeRegistry.types[name]; exists {
-		// This is a collision - we need qualified names for both
-
-		// If this is the first collision with this name, we need to rename the original entry
-		if entry.Count == 1 && entry.FinalName == name {
-			// Update the original entry to use a qualified name
-			origFullID := entry.PkgPath + "." + entry.Name
-
-			// Calculate the original's qualified name
-			origQualifiedName := SanitizeSchemaName(entry.PkgPath + "_" + entry.Name)
-			entry.FinalName = origQualifiedName
-
-			// Update map to point to the same entry with full ID
-			globalTypeRegistry.types[origFullID] = entry
-			delete(globalTypeRegistry.types, name)
-		}
-
-		// Register this new type with its package-qualified name
-		qualifiedName := SanitizeSchemaName(pkgPath + "_" + name)
-		globalTypeRegistry.types[fullID] = &TypeRegistryEntry{
-			Name:      name,
-			PkgPath:   pkgPath,
-			Count:     1,
-			FinalName: qualifiedName,
-		}
-
-		// Return the qualified name when there's a collision
-		return qualifiedName
-	}
-
-	// First time seeing this name, register with the simple name
-	globalTypeRegistry.types[name] = &TypeRegistryEntry{
+	entry := &TypeRegistryEntry{
 		Name:      name,
 		PkgPath:   pkgPath,
 		Count:     1,
-		FinalName: name, // Initially use the simple name
+		FinalName: name,
+	}
+	r.types[fullID] = entry
+
+	siblings := append(r.names[name], entry)
+	r.names[name] = siblings
+	if len(siblings) > 1 {
+		// This is a collision - every type with this name gets a qualified name
+		for _, sibling := range siblings {
+			sibling.FinalName = SanitizeSchemaName(sibling.PkgPath + "_" + sibling.Name)
+		}
 	}
 
-	// Also register with the full ID for exact lookups
-	globalTypeRegistry.types[fullID] = globalTypeRegistry.types[name]
+	return entry.FinalName
+}
+
+// Name returns the current name of a registered type, or an empty string
+// if the type has not been registered.
+func (r *TypeRegistry) Name(t reflect.Type) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	// Return simple name when there's no collision
-	return name
+	if entry, exists := r.types[t.PkgPath()+"."+t.Name()]; exists {
+		return entry.FinalName
+	}
+	return ""
 }
 
 // SanitizeSchemaName converts a fully qualified type name to a valid schema name
@@ -0,0 +1,92 @@
+package openapi
+
+import "github.com/joakimcarlsson/go-router/metadata"
+
+// componentsPrefix is the location of the reusable components in the specification
+const componentsPrefix = "#/components/"
+
+// WithParameterComponent registers a reusable parameter under name, emitted
+// in components/parameters. Routes reference it with docs.WithParameterRef,
+// which keeps shared parameters such as pagination defined in one place.
+func (g *Generator) WithParameterComponent(name string, p metadata.Parameter) {
+	g.parameters[name] = p
+}
+
+// parameterComponents converts the registered parameter components
+func (g *Generator) parameterComponents() map[string]Parameter {
+	if len(g.parameters) == 0 {
+		return nil
+	}
+	components := make(map[string]Parameter, len(g.parameters))
+	for name, p := range g.parameters {
+		components[name] = g.placeParameterExamples(ParameterFromMetadataParameter(p))
+	}
+	return components
+}
+
+// convertParameters converts the parameters of a route, emitting references
+// for parameter components. Parameters described inline that duplicate a
+// referenced component, such as automatically documented path parameters,
+// are dropped.
+func (g *Generator) convertParameters(params []metadata.Parameter) []Parameter {
+	referenced := make(map[string]bool)
+	for _, p := range params {
+		if component, ok := g.parameters[p.Ref]; p.Ref != "" && ok {
+			referenced[component.In+" "+component.Name] = true
+		}
+	}
+
+	parameters := make([]Parameter, 0, len(params))
+	for _, p := range params {
+		if p.Ref != "" {
+			parameters = append(parameters, Parameter{Ref: componentsPrefix + "parameters/" + p.Ref})
+			continue
+		}
+		if referenced[p.In+" "+p.Name] {
+			continue
+		}
+		parameters = append(parameters, g.placeParameterExamples(ParameterFromMetadataParameter(p)))
+	}
+	return parameters
+}
+
+// WithRequestBodyComponent registers a reusable request body under name,
+// emitted in components/requestBodies. Routes reference it with
+// docs.WithRequestBodyRef when many endpoints accept the same payload.
+func (g *Generator) WithRequestBodyComponent(name string, rb metadata.RequestBody) {
+	g.requestBodies[name] = rb
+}
+
+// requestBodyComponents converts the registered request body components
+func (g *generation) requestBodyComponents() map[string]RequestBody {
+	if len(g.namedRequestBodies) == 0 {
+		return nil
+	}
+	components := make(map[string]RequestBody, len(g.namedRequestBodies))
+	for name, rb := range g.namedRequestBodies {
+		components[name] = *g.convertRequestBody(&rb)
+	}
+	return components
+}
+
+// convertRequestBody converts a request body, emitting a reference for a
+// request body component and referencing component schemas from its content.
+func (g *generation) convertRequestBody(rb *metadata.RequestBody) *RequestBody {
+	if rb == nil {
+		return nil
+	}
+	if rb.Ref != "" {
+		return &RequestBody{Ref: componentsPrefix + "requestBodies/" + rb.Ref}
+	}
+
+	requestBody := RequestBodyFromMetadataRequestBody(rb)
+	for contentType, mediaType := range requestBody.Content {
+		schemaName := g.generateSchemaName(mediaType.Schema)
+		if schemaName != "" && g.schemas[schemaName].Type != "" {
+			mediaType.SchemaRef = g.createSchemaReference(schemaName)
+			mediaType.Schema = Schema{}
+			requestBody.Content[contentType] = mediaType
+		}
+	}
+	return requestBody
+}
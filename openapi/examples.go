@@ -0,0 +1,87 @@
+package openapi
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// WithExamplesIncludeOptional sets whether the examples generated for the
+// schemas of Go structs include optional fields, true by default. Optional
+// fields are pointers and fields tagged omitempty, unless they are tagged
+// validate:"required"; leaving them out keeps examples from suggesting that
+// they are always present. Examples set with an example tag are left out
+// too when their field is optional.
+func (g *Generator) WithExamplesIncludeOptional(include bool) {
+	g.omitOptionalExamples = !include
+}
+
+// withoutOptionalFields returns a copy of a generated example for type t,
+// and the struct values nested in it, without the members of optional fields.
+func withoutOptionalFields(example interface{}, t reflect.Type) interface{} {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return example
+	}
+
+	switch v := example.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct {
+			return example
+		}
+		pruned := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			field, ok := fieldByExampleKey(t, key)
+			if !ok {
+				pruned[key] = value
+				continue
+			}
+			if isOptionalField(field) {
+				continue
+			}
+			pruned[key] = withoutOptionalFields(value, field.Type)
+		}
+		return pruned
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return example
+		}
+		pruned := make([]interface{}, len(v))
+		for i, value := range v {
+			pruned[i] = withoutOptionalFields(value, t.Elem())
+		}
+		return pruned
+	}
+	return example
+}
+
+// fieldByExampleKey returns the exported struct field an example member
+// was generated from, named after its json tag or else the field name.
+func fieldByExampleKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		if name == key {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// isOptionalField reports whether a field may be absent from the JSON form
+// of its struct: pointers and omitempty fields that aren't required.
+func isOptionalField(field reflect.StructField) bool {
+	if slices.Contains(strings.Split(field.Tag.Get("validate"), ","), "required") {
+		return false
+	}
+	options := strings.Split(field.Tag.Get("json"), ",")[1:]
+	return field.Type.Kind() == reflect.Ptr || slices.Contains(options, "omitempty")
+}
@@ -2,6 +2,7 @@ package openapi
 
 import (
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -13,8 +14,33 @@ type Generator struct {
 	info            Info
 	securitySchemes map[string]SecurityScheme
 	servers         []Server
-	schemas         map[string]Schema
-	routeInfo       []RouteInfo
+	// strictValidation makes Generate panic when Validate reports errors
+	strictValidation bool
+	// schemaNamer overrides the component name of schemas generated from Go types
+	schemaNamer func(reflect.Type) string
+	// propertyNaming names the properties of struct fields without a json tag
+	propertyNaming func(string) string
+	// omitOptionalExamples leaves optional fields out of generated examples
+	omitOptionalExamples bool
+	// openAPIVersion is the version of the OpenAPI specification to generate
+	openAPIVersion string
+	// parameters holds the reusable parameter components
+	parameters map[string]metadata.Parameter
+	// requestBodies holds the reusable request body components
+	requestBodies map[string]metadata.RequestBody
+}
+
+// generation holds the state of a single Generate call, so that concurrent
+// calls, such as those of a handler serving the spec, don't share it
+type generation struct {
+	*Generator
+	// routeInfo holds the routes with the schema names assigned by the call
+	routeInfo []RouteInfo
+	// namedRequestBodies holds the request body components with the schema
+	// names assigned by the call
+	namedRequestBodies map[string]metadata.RequestBody
+	// schemas holds the collected schema components
+	schemas map[string]Schema
 }
 
 // NewGenerator creates a new OpenAPI generator
@@ -23,11 +49,29 @@ func NewGenerator(info Info) *Generator {
 		info:            info,
 		securitySchemes: make(map[string]SecurityScheme),
 		servers:         make([]Server, 0),
-		schemas:         make(map[string]Schema),
-		routeInfo:       make([]RouteInfo, 0),
+		openAPIVersion:  "3.0.0",
+		parameters:      make(map[string]metadata.Parameter),
+		requestBodies:   make(map[string]metadata.RequestBody),
 	}
 }
 
+// Info returns the info section of the generated specification
+func (g *Generator) Info() Info {
+	return g.info
+}
+
+// WithOpenAPIVersion sets the version of the OpenAPI specification to
+// generate, "3.0.0" by default. Where the versions differ, such as for
+// parameter examples, the output follows the conventions of that version.
+func (g *Generator) WithOpenAPIVersion(version string) {
+	g.openAPIVersion = version
+}
+
+// isOpenAPI31 reports whether the generator targets OpenAPI 3.1
+func (g *Generator) isOpenAPI31() bool {
+	return strings.HasPrefix(g.openAPIVersion, "3.1")
+}
+
 // WithSecurityScheme adds a security scheme to the OpenAPI specification
 func (g *Generator) WithSecurityScheme(name string, scheme SecurityScheme) {
 	g.securitySchemes[name] = scheme
@@ -61,6 +105,18 @@ func (g *Generator) WithAPIKey(name, description, in, paramName string) {
 	})
 }
 
+// WithAPIKeyQuery adds an API key authentication security scheme where the
+// key is passed in the query parameter paramName
+func (g *Generator) WithAPIKeyQuery(name, description, paramName string) {
+	g.WithAPIKey(name, description, "query", paramName)
+}
+
+// WithAPIKeyCookie adds an API key authentication security scheme where the
+// key is passed in the cookie cookieName
+func (g *Generator) WithAPIKeyCookie(name, description, cookieName string) {
+	g.WithAPIKey(name, description, "cookie", cookieName)
+}
+
 // WithOAuth2ImplicitFlow adds an OAuth2 security scheme with implicit flow
 func (g *Generator) WithOAuth2ImplicitFlow(name, description, authorizationURL string, scopes map[string]string) {
 	g.WithSecurityScheme(name, SecurityScheme{
@@ -135,8 +191,28 @@ func (g *Generator) WithServer(url string, description string) {
 	})
 }
 
+// buildSchemas names the schemas used by the routes and collects the named
+// types as components. Routes excluded from the documentation are skipped.
+func (g *Generator) buildSchemas(routes []RouteInfo) *generation {
+	named, requestBodies := g.nameSchemas(documentedRoutes(routes))
+	gen := &generation{
+		Generator:          g,
+		routeInfo:          named,
+		namedRequestBodies: requestBodies,
+		schemas:            make(map[string]Schema),
+	}
+	gen.collectSchemas()
+	return gen
+}
+
 // collectSchemas recursively collects schemas from route info
-func (g *Generator) collectSchemas() {
+func (g *generation) collectSchemas() {
+	for _, rb := range g.namedRequestBodies {
+		for _, mediaType := range rb.Content {
+			g.collectSchemaComponents(SchemaFromMetadataSchema(mediaType.Schema))
+		}
+	}
+
 	for _, route := range g.routeInfo {
 		// Collect from request bodies
 		if reqBody := route.RequestBody(); reqBody != nil {
@@ -161,7 +237,7 @@ func (g *Generator) collectSchemas() {
 }
 
 // collectSchemaComponents recursively collects component schemas
-func (g *Generator) collectSchemaComponents(schema Schema) {
+func (g *generation) collectSchemaComponents(schema Schema) {
 	// If it's an array type, process the item type
 	if schema.Type == "array" && schema.Items != nil {
 		// Register the array item type if it's an object
@@ -177,16 +253,50 @@ func (g *Generator) collectSchemaComponents(schema Schema) {
 
 	// If it's a struct type, register it as a component
 	if schema.Type == "object" && schema.Properties != nil && schema.TypeName != "" {
+		// Recurse into properties
+		for _, prop := range schema.Properties {
+			g.collectSchemaComponents(prop)
+		}
+
 		name := g.generateSchemaName(schema)
 		if name != "" {
-			g.schemas[name] = schema
+			g.schemas[name] = g.withNullableRefs(schema)
 		}
+	}
+}
 
-		// Recurse into properties
-		for _, prop := range schema.Properties {
-			g.collectSchemaComponents(prop)
+// withNullableRefs replaces the nullable struct properties of a component,
+// the fields declared as pointers to structs, with nullable references to
+// the component of the referenced struct
+func (g *Generator) withNullableRefs(schema Schema) Schema {
+	properties := make(map[string]Schema, len(schema.Properties))
+	for name, prop := range schema.Properties {
+		refName := g.generateSchemaName(prop)
+		if prop.Nullable && prop.Type == "object" && prop.Properties != nil && refName != "" {
+			prop = g.nullableRef(refName, prop.Description)
+		}
+		properties[name] = prop
+	}
+	schema.Properties = properties
+	return schema
+}
+
+// nullableRef creates a nullable reference to a schema component. A $ref
+// cannot carry siblings, so OpenAPI 3.0 wraps it in allOf with nullable,
+// while OpenAPI 3.1 pairs it with the "null" type.
+func (g *Generator) nullableRef(schemaName, description string) Schema {
+	ref := Schema{Ref: "#/components/schemas/" + schemaName}
+	if g.isOpenAPI31() {
+		return Schema{
+			Description: description,
+			AnyOf:       []Schema{ref, {Type: "null"}},
 		}
 	}
+	return Schema{
+		Description: description,
+		AllOf:       []Schema{ref},
+		Nullable:    true,
+	}
 }
 
 // generateSchemaName generates a name for a schema based on its structure
@@ -320,10 +430,7 @@ func WithJSONResponse[T any](statusCode int, description string) RouteOption {
 
 		// Special handling for array types
 		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
-			elemType := t.Elem()
-			// Register the element type to ensure it appears in components
-			itemTypeName := metadata.RegisterType(elemType)
-			sanitizedName := metadata.SanitizeSchemaName(itemTypeName)
+			sanitizedName := metadata.SanitizeSchemaName(t.Elem().Name())
 
 			m.Responses[strconv.Itoa(statusCode)] = Response{
 				Description: description,
@@ -525,18 +632,25 @@ func WithRequestBodyExample[T any](description string, required bool, example T)
 	}
 }
 
-// Generate creates an OpenAPI specification from the collected route information
+// Generate creates an OpenAPI specification from the collected route information.
+// Routes excluded with docs.WithExcludeFromDocs are left out.
 func (g *Generator) Generate(routes []RouteInfo) *Spec {
-	g.routeInfo = routes
-	g.collectSchemas()
+	g.validateOrPanic(routes)
+	return g.buildSchemas(routes).spec()
+}
 
+// spec assembles the specification from the named routes and the collected
+// schema components
+func (g *generation) spec() *Spec {
 	spec := &Spec{
-		OpenAPI: "3.0.0",
+		OpenAPI: g.openAPIVersion,
 		Info:    g.info,
 		Paths:   make(map[string]PathItem),
 		Components: &Components{
 			SecuritySchemes: g.securitySchemes,
 			Schemas:         g.schemas,
+			Parameters:      g.parameterComponents(),
+			RequestBodies:   g.requestBodyComponents(),
 		},
 	}
 
@@ -544,25 +658,13 @@ func (g *Generator) Generate(routes []RouteInfo) *Spec {
 		spec.Servers = g.servers
 	}
 
-	for _, route := range routes {
+	for _, route := range g.routeInfo {
 		pathItem, ok := spec.Paths[route.Path()]
 		if !ok {
 			pathItem = PathItem{}
 		}
 
-		var requestBody *RequestBody
-		if rb := route.RequestBody(); rb != nil {
-			requestBody = RequestBodyFromMetadataRequestBody(rb)
-
-			for contentType, mediaType := range requestBody.Content {
-				schemaName := g.generateSchemaName(mediaType.Schema)
-				if schemaName != "" && g.schemas[schemaName].Type != "" {
-					mediaType.SchemaRef = g.createSchemaReference(schemaName)
-					mediaType.Schema = Schema{}
-					requestBody.Content[contentType] = mediaType
-				}
-			}
-		}
+		requestBody := g.convertRequestBody(route.RequestBody())
 
 		// Convert responses
 		responses := make(map[string]Response)
@@ -595,10 +697,7 @@ func (g *Generator) Generate(routes []RouteInfo) *Spec {
 		}
 
 		// Convert parameters
-		parameters := make([]Parameter, len(route.Parameters()))
-		for i, param := range route.Parameters() {
-			parameters[i] = ParameterFromMetadataParameter(param)
-		}
+		parameters := g.convertParameters(route.Parameters())
 
 		// Convert security requirements
 		security := make([]SecurityRequirement, len(route.Security()))
@@ -620,6 +719,8 @@ func (g *Generator) Generate(routes []RouteInfo) *Spec {
 			Responses:   responses,
 			Security:    security,
 			Deprecated:  route.IsDeprecated(),
+			Servers:     convertServers(routeServers(route)),
+			Extensions:  routeExtensions(route),
 		}
 
 		switch route.Method() {
@@ -633,12 +734,49 @@ func (g *Generator) Generate(routes []RouteInfo) *Spec {
 			pathItem.Delete = operation
 		case "PATCH":
 			pathItem.Patch = operation
+		case "OPTIONS":
+			pathItem.Options = operation
+		case "HEAD":
+			pathItem.Head = operation
+		case "TRACE":
+			pathItem.Trace = operation
 		}
 
 		spec.Paths[route.Path()] = pathItem
 	}
 
-	delete(spec.Paths, "/openapi.json")
-
+	if g.isOpenAPI31() {
+		withNullTypes(spec)
+	}
 	return spec
 }
+
+// placeParameterExamples moves the examples of a parameter to where the
+// targeted OpenAPI version expects them. OpenAPI 3.1 uses named "examples",
+// so a single example becomes the "default" one. For OpenAPI 3.0 the single
+// "example" is used, taking the first named example when none is set.
+func (g *Generator) placeParameterExamples(p Parameter) Parameter {
+	if g.isOpenAPI31() {
+		if p.Example != nil {
+			if p.Examples == nil {
+				p.Examples = make(map[string]Example)
+			}
+			if _, ok := p.Examples["default"]; !ok {
+				p.Examples["default"] = Example{Value: p.Example}
+			}
+			p.Example = nil
+		}
+		return p
+	}
+
+	if p.Example == nil && len(p.Examples) > 0 {
+		names := make([]string, 0, len(p.Examples))
+		for name := range p.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		p.Example = p.Examples[names[0]].Value
+	}
+	p.Examples = nil
+	return p
+}
@@ -0,0 +1,1022 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/go-router/docs"
+	"github.com/joakimcarlsson/go-router/metadata"
+	"github.com/joakimcarlsson/go-router/openapi"
+)
+
+func newTestGenerator() *openapi.Generator {
+	return openapi.NewGenerator(openapi.Info{Title: "Test API", Version: "1.0.0"})
+}
+
+func routeInfo(m metadata.RouteMetadata) openapi.RouteInfo {
+	if m.Responses == nil {
+		m.Responses = make(map[string]metadata.Response)
+	}
+	return openapi.RouteInfoFromMetadata(m)
+}
+
+func expectSingleError(t *testing.T, errs []error, contains string) {
+	t.Helper()
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+	if !strings.Contains(errs[0].Error(), contains) {
+		t.Fatalf("expected error containing %q, got %q", contains, errs[0])
+	}
+}
+
+func TestValidateUndocumentedPathParam(t *testing.T) {
+	g := newTestGenerator()
+	errs := g.Validate([]openapi.RouteInfo{
+		routeInfo(metadata.RouteMetadata{Method: "GET", Path: "/users/{id}"}),
+	})
+	expectSingleError(t, errs, `path parameter "id"`)
+
+	errs = g.Validate([]openapi.RouteInfo{
+		routeInfo(metadata.RouteMetadata{
+			Method:     "GET",
+			Path:       "/users/{id}",
+			Parameters: []metadata.Parameter{{Name: "id", In: "path", Required: true}},
+		}),
+	})
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+}
+
+func TestValidateUndefinedSecurityScheme(t *testing.T) {
+	g := newTestGenerator()
+	g.WithBearerAuth("bearerAuth", "Bearer token")
+
+	errs := g.Validate([]openapi.RouteInfo{
+		routeInfo(metadata.RouteMetadata{
+			Method: "GET",
+			Path:   "/me",
+			Security: []metadata.SecurityRequirement{
+				{"bearerAuth": {}},
+				{"oauth2": {"read"}},
+			},
+		}),
+	})
+	expectSingleError(t, errs, `security scheme "oauth2"`)
+}
+
+func TestValidateDuplicateOperationID(t *testing.T) {
+	g := newTestGenerator()
+	errs := g.Validate([]openapi.RouteInfo{
+		routeInfo(metadata.RouteMetadata{Method: "GET", Path: "/users", OperationID: "listUsers"}),
+		routeInfo(metadata.RouteMetadata{Method: "GET", Path: "/people", OperationID: "listUsers"}),
+	})
+	expectSingleError(t, errs, `operationId "listUsers"`)
+}
+
+func TestGenerateStrictValidation(t *testing.T) {
+	routes := []openapi.RouteInfo{
+		routeInfo(metadata.RouteMetadata{Method: "GET", Path: "/users/{id}"}),
+	}
+
+	g := newTestGenerator()
+	g.Generate(routes)
+
+	g.WithStrictValidation(true)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Generate to panic with strict validation enabled")
+		}
+	}()
+	g.Generate(routes)
+}
+
+func TestValidateOptionalRequestBodyWarning(t *testing.T) {
+	create := metadata.RouteMetadata{Method: "POST", Path: "/orders"}
+	docs.WithJSONRequestBody[OrderInput](false, "The order to place")(&create)
+	search := metadata.RouteMetadata{Method: "GET", Path: "/orders"}
+	docs.WithJSONRequestBody[OrderInput](false, "Search filters")(&search)
+	routes := []openapi.RouteInfo{routeInfo(create), routeInfo(search)}
+
+	g := newTestGenerator()
+	errs := g.Validate(routes)
+	expectSingleError(t, errs, "POST /orders: request body is not required")
+	var warning *openapi.ValidationWarning
+	if !errors.As(errs[0], &warning) {
+		t.Fatalf("expected a ValidationWarning, got %T", errs[0])
+	}
+
+	g.WithStrictValidation(true)
+	spec := g.Generate(routes)
+	data, err := json.Marshal(spec.Paths["/orders"].Post.RequestBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"required":false`) {
+		t.Fatalf("expected an explicit required false, got %s", data)
+	}
+}
+
+func TestGenerateOptionsAndHeadOperations(t *testing.T) {
+	g := newTestGenerator()
+	spec := g.Generate([]openapi.RouteInfo{
+		routeInfo(metadata.RouteMetadata{Method: "OPTIONS", Path: "/users", Summary: "Preflight"}),
+		routeInfo(metadata.RouteMetadata{Method: "HEAD", Path: "/users", Summary: "Probe"}),
+	})
+
+	item := spec.Paths["/users"]
+	if item.Options == nil || item.Options.Summary != "Preflight" {
+		t.Fatalf("expected OPTIONS operation under options, got %+v", item.Options)
+	}
+	if item.Head == nil || item.Head.Summary != "Probe" {
+		t.Fatalf("expected HEAD operation under head, got %+v", item.Head)
+	}
+}
+
+type NamerUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestGenerateWithSchemaNamer(t *testing.T) {
+	m := metadata.RouteMetadata{Method: "POST", Path: "/users"}
+	docs.WithJSONRequestBody[NamerUser](true, "User to create")(&m)
+	docs.WithJSONResponse[[]NamerUser](200, "All users")(&m)
+
+	g := newTestGenerator()
+	g.WithSchemaNamer(func(t reflect.Type) string {
+		return strings.TrimPrefix(t.Name(), "Namer") + "DTO"
+	})
+	spec := g.Generate([]openapi.RouteInfo{routeInfo(m)})
+
+	if _, ok := spec.Components.Schemas["UserDTO"]; !ok {
+		t.Fatalf("expected UserDTO component, got %v", keys(spec.Components.Schemas))
+	}
+	if _, ok := spec.Components.Schemas["NamerUser"]; ok {
+		t.Fatal("expected default component name to be replaced")
+	}
+
+	op := spec.Paths["/users"].Post
+	if ref := op.RequestBody.Content["application/json"].SchemaRef; ref == nil || ref.Ref != "#/components/schemas/UserDTO" {
+		t.Fatalf("expected request body to reference UserDTO, got %+v", ref)
+	}
+	if items := op.Responses["200"].Content["application/json"].Schema.Items; items == nil || items.Ref != "#/components/schemas/UserDTO" {
+		t.Fatalf("expected response items to reference UserDTO, got %+v", items)
+	}
+}
+
+func keys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	out := keys(m)
+	sort.Strings(out)
+	return out
+}
+
+// Contact shares its name with openapi.Contact to exercise name collisions.
+type Contact struct {
+	Phone string `json:"phone"`
+}
+
+func TestGeneratorsDoNotShareSchemaNames(t *testing.T) {
+	first := metadata.RouteMetadata{Method: "GET", Path: "/contact"}
+	docs.WithJSONResponse[Contact](200, "Contact")(&first)
+
+	second := metadata.RouteMetadata{Method: "GET", Path: "/contact"}
+	docs.WithJSONResponse[openapi.Contact](200, "Contact")(&second)
+
+	for i, m := range []metadata.RouteMetadata{first, second} {
+		spec := newTestGenerator().Generate([]openapi.RouteInfo{routeInfo(m)})
+		if len(spec.Components.Schemas) != 1 {
+			t.Fatalf("api %d: expected a single component, got %v", i, keys(spec.Components.Schemas))
+		}
+		if _, ok := spec.Components.Schemas["Contact"]; !ok {
+			t.Fatalf("api %d: expected unqualified Contact component, got %v", i, keys(spec.Components.Schemas))
+		}
+	}
+}
+
+func TestCollisionNamesIndependentOfOrder(t *testing.T) {
+	local := metadata.RouteMetadata{Method: "GET", Path: "/local"}
+	docs.WithJSONResponse[Contact](200, "Contact")(&local)
+
+	other := metadata.RouteMetadata{Method: "GET", Path: "/other"}
+	docs.WithJSONResponse[openapi.Contact](200, "Contact")(&other)
+
+	forward := newTestGenerator().Generate([]openapi.RouteInfo{routeInfo(local), routeInfo(other)})
+	backward := newTestGenerator().Generate([]openapi.RouteInfo{routeInfo(other), routeInfo(local)})
+
+	if len(forward.Components.Schemas) != 2 {
+		t.Fatalf("expected 2 components, got %v", keys(forward.Components.Schemas))
+	}
+	for name := range forward.Components.Schemas {
+		if name == "Contact" {
+			t.Fatal("expected colliding types to use qualified names")
+		}
+		if _, ok := backward.Components.Schemas[name]; !ok {
+			t.Fatalf("component %q missing when routes are registered in reverse order: %v",
+				name, keys(backward.Components.Schemas))
+		}
+	}
+
+	for _, path := range []string{"/local", "/other"} {
+		f := forward.Paths[path].Get.Responses["200"].Content["application/json"].SchemaRef
+		b := backward.Paths[path].Get.Responses["200"].Content["application/json"].SchemaRef
+		if f == nil || b == nil || f.Ref != b.Ref {
+			t.Fatalf("%s: expected identical refs, got %+v and %+v", path, f, b)
+		}
+	}
+}
+
+func TestOperationExtensions(t *testing.T) {
+	m := metadata.RouteMetadata{Method: "DELETE", Path: "/users"}
+	docs.WithExtension("x-internal", true)(&m)
+	docs.WithExtension("codegen-request-body-name", "user")(&m)
+
+	spec := newTestGenerator().Generate([]openapi.RouteInfo{routeInfo(m)})
+	data, err := json.Marshal(spec.Paths["/users"].Delete)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var op map[string]interface{}
+	if err := json.Unmarshal(data, &op); err != nil {
+		t.Fatal(err)
+	}
+	if op["x-internal"] != true {
+		t.Fatalf("expected x-internal extension, got %s", data)
+	}
+	if op["x-codegen-request-body-name"] != "user" {
+		t.Fatalf("expected prefixed extension, got %s", data)
+	}
+	if _, ok := op["responses"]; !ok {
+		t.Fatalf("expected standard fields to be kept, got %s", data)
+	}
+}
+
+func TestTryItOutDisabled(t *testing.T) {
+	m := metadata.RouteMetadata{Method: "DELETE", Path: "/accounts"}
+	docs.WithTryItOutDisabled()(&m)
+
+	spec := newTestGenerator().Generate([]openapi.RouteInfo{routeInfo(m)})
+	data, err := json.Marshal(spec.Paths["/accounts"].Delete)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"x-tryItOut":false`) {
+		t.Fatalf("expected the x-tryItOut extension, got %s", data)
+	}
+}
+
+func TestOperationServers(t *testing.T) {
+	upload := metadata.RouteMetadata{Method: "POST", Path: "/uploads"}
+	docs.WithOperationServer("https://uploads.example.com", "Legacy upload host")(&upload)
+	list := metadata.RouteMetadata{Method: "GET", Path: "/uploads"}
+
+	spec := newTestGenerator().Generate([]openapi.RouteInfo{routeInfo(upload), routeInfo(list)})
+	data, err := json.Marshal(spec.Paths["/uploads"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var path map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &path); err != nil {
+		t.Fatal(err)
+	}
+
+	servers, ok := path["post"]["servers"].([]interface{})
+	if !ok || len(servers) != 1 {
+		t.Fatalf("expected the upload operation to have one server, got %v", path["post"]["servers"])
+	}
+	if server := servers[0].(map[string]interface{}); server["url"] != "https://uploads.example.com" || server["description"] != "Legacy upload host" {
+		t.Fatalf("unexpected server %v", server)
+	}
+	if _, ok := path["get"]["servers"]; ok {
+		t.Fatalf("expected no servers on the list operation, got %v", path["get"]["servers"])
+	}
+}
+
+func TestQueryParamDefault(t *testing.T) {
+	m := metadata.RouteMetadata{Method: "GET", Path: "/orders"}
+	docs.WithQueryParamDefault("limit", "integer", false, "Page size", 20)(&m)
+
+	spec := newTestGenerator().Generate([]openapi.RouteInfo{routeInfo(m)})
+	data, err := json.Marshal(spec.Paths["/orders"].Get.Parameters[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"schema":{"type":"integer","default":20}`; !strings.Contains(string(data), want) {
+		t.Fatalf("expected %s in %s", want, data)
+	}
+}
+
+func TestSchemaExtensions(t *testing.T) {
+	data, err := json.Marshal(openapi.Schema{
+		Type:       "string",
+		Extensions: map[string]interface{}{"x-go-type": "uuid.UUID"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"type":"string","x-go-type":"uuid.UUID"}` {
+		t.Fatalf("unexpected schema JSON %s", data)
+	}
+}
+
+type ConcurrentItem struct {
+	Name string `json:"name"`
+}
+
+// TestConcurrentGenerate is meant to run with -race, as handlers generate the
+// spec for concurrent requests.
+func TestConcurrentGenerate(t *testing.T) {
+	list := metadata.RouteMetadata{Method: "GET", Path: "/items"}
+	docs.WithJSONResponse[[]ConcurrentItem](200, "Items")(&list)
+	create := metadata.RouteMetadata{Method: "POST", Path: "/items"}
+	docs.WithJSONRequestBody[ConcurrentItem](true, "Item")(&create)
+	routes := []openapi.RouteInfo{routeInfo(list), routeInfo(create)}
+	g := newTestGenerator()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if spec := g.Generate(routes); spec.Components.Schemas["ConcurrentItem"].Type != "object" {
+				t.Errorf("expected the item schema, got %v", keys(spec.Components.Schemas))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+type AuditEntry struct {
+	Action string `json:"action"`
+}
+
+func TestGenerateSkipsExcludedRoutes(t *testing.T) {
+	hidden := metadata.RouteMetadata{Method: "GET", Path: "/internal"}
+	docs.WithJSONResponse[AuditEntry](200, "Audit entry")(&hidden)
+	docs.WithExcludeFromDocs()(&hidden)
+	public := metadata.RouteMetadata{Method: "GET", Path: "/health"}
+
+	spec := newTestGenerator().Generate([]openapi.RouteInfo{routeInfo(hidden), routeInfo(public)})
+	if _, ok := spec.Paths["/internal"]; ok || len(spec.Paths) != 1 {
+		t.Fatalf("expected only the documented route, got %v", keys(spec.Paths))
+	}
+	if len(spec.Components.Schemas) != 0 {
+		t.Fatalf("expected no schemas from the excluded route, got %v", keys(spec.Components.Schemas))
+	}
+}
+
+// basicRoute is a RouteInfo that only has the methods RouteInfo requires
+type basicRoute struct {
+	openapi.RouteInfo
+}
+
+func TestRouteInfoWithoutOptionalMethods(t *testing.T) {
+	m := metadata.RouteMetadata{Method: "DELETE", Path: "/users"}
+	docs.WithExtension("x-internal", true)(&m)
+	docs.WithOperationServer("https://admin.example.com", "Admin host")(&m)
+
+	spec := newTestGenerator().Generate([]openapi.RouteInfo{basicRoute{routeInfo(m)}})
+	op := spec.Paths["/users"].Delete
+	if op == nil {
+		t.Fatal("expected the route to be documented")
+	}
+	if len(op.Extensions) != 0 {
+		t.Fatalf("expected no extensions from a route without an Extensions method, got %v", op.Extensions)
+	}
+	if len(op.Servers) != 0 {
+		t.Fatalf("expected no servers from a route without a Servers method, got %v", op.Servers)
+	}
+}
+
+func TestParameterExamplesByVersion(t *testing.T) {
+	m := metadata.RouteMetadata{Method: "GET", Path: "/orders"}
+	docs.WithQueryParam("status", "string", false, "Order status", "open")(&m)
+	docs.WithQueryParam("sort", "string", false, "Sort order", nil)(&m)
+	docs.WithParamExamples("sort", map[string]interface{}{"oldest": "created", "newest": "-created"})(&m)
+
+	tests := []struct {
+		version string
+		status  string
+		sort    string
+	}{
+		{"3.0.0", `"example":"open"`, `"example":"-created"`},
+		{"3.1.0", `"examples":{"default":{"value":"open"}}`, `"examples":{"newest":{"value":"-created"},"oldest":{"value":"created"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			g := newTestGenerator()
+			g.WithOpenAPIVersion(tt.version)
+			spec := g.Generate([]openapi.RouteInfo{routeInfo(m)})
+			if spec.OpenAPI != tt.version {
+				t.Fatalf("expected openapi %s, got %s", tt.version, spec.OpenAPI)
+			}
+
+			params := spec.Paths["/orders"].Get.Parameters
+			for i, want := range []string{tt.status, tt.sort} {
+				data, err := json.Marshal(params[i])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(string(data), want) {
+					t.Fatalf("expected %s in %s", want, data)
+				}
+				if strings.Contains(string(data), `"schema":{"type":"string","example"`) {
+					t.Fatalf("expected no example on the parameter schema, got %s", data)
+				}
+				if tt.version == "3.0.0" && strings.Contains(string(data), `"examples"`) ||
+					tt.version == "3.1.0" && strings.Contains(string(data), `"example":`) {
+					t.Fatalf("unexpected example placement for %s: %s", tt.version, data)
+				}
+			}
+		})
+	}
+}
+
+func TestWithParamExamplesUnknownParameter(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for an undeclared parameter")
+		}
+	}()
+	docs.WithParamExamples("missing", map[string]interface{}{"a": 1})(&metadata.RouteMetadata{})
+}
+
+type Nickname struct {
+	Nickname *string `json:"nickname"`
+}
+
+func TestNullablePointerFieldByVersion(t *testing.T) {
+	m := metadata.RouteMetadata{Method: "GET", Path: "/nicknames"}
+	docs.WithJSONResponse[Nickname](200, "Nickname")(&m)
+
+	tests := []struct {
+		version  string
+		nickname string
+	}{
+		{"3.0.0", `{"type":"string","example":"example","nullable":true}`},
+		{"3.1.0", `{"type":["string","null"],"example":"example"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			g := newTestGenerator()
+			g.WithOpenAPIVersion(tt.version)
+			spec := g.Generate([]openapi.RouteInfo{routeInfo(m)})
+
+			data, err := json.Marshal(spec.Components.Schemas["Nickname"].Properties["nickname"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.nickname {
+				t.Fatalf("expected %s, got %s", tt.nickname, data)
+			}
+		})
+	}
+}
+
+type APIError struct {
+	Message string `json:"message"`
+}
+
+func TestDefaultAndRangeResponses(t *testing.T) {
+	m := metadata.RouteMetadata{Method: "GET", Path: "/orders"}
+	docs.WithResponse(200, "Orders")(&m)
+	docs.WithRangeResponse[APIError]("4xx", "Client error")(&m)
+	docs.WithDefaultResponse[APIError]("Unexpected error")(&m)
+
+	spec := newTestGenerator().Generate([]openapi.RouteInfo{routeInfo(m)})
+	responses := spec.Paths["/orders"].Get.Responses
+
+	for code, description := range map[string]string{"default": "Unexpected error", "4XX": "Client error"} {
+		response, ok := responses[code]
+		if !ok {
+			t.Fatalf("expected a %q response, got %v", code, keys(responses))
+		}
+		if response.Description != description {
+			t.Fatalf("expected %q description %q, got %q", code, description, response.Description)
+		}
+		ref := response.Content["application/json"].SchemaRef
+		if ref == nil || ref.Ref != "#/components/schemas/APIError" {
+			t.Fatalf("expected %q to reference the APIError schema, got %+v", code, response.Content)
+		}
+	}
+}
+
+func TestWithRangeResponseRejectsInvalidRange(t *testing.T) {
+	for _, code := range []string{"4X", "6XX", "404", "XXX"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected %q to be rejected", code)
+				}
+			}()
+			docs.WithRangeResponse[APIError](code, "invalid")
+		}()
+	}
+}
+
+func TestParameterComponents(t *testing.T) {
+	g := newTestGenerator()
+	g.WithParameterComponent("UserID", metadata.Parameter{
+		Name: "id", In: "path", Required: true, Schema: metadata.Schema{Type: "integer"},
+	})
+	g.WithParameterComponent("Take", metadata.Parameter{
+		Name: "take", In: "query", Schema: metadata.Schema{Type: "integer"},
+	})
+
+	m := metadata.RouteMetadata{
+		Method: "GET",
+		Path:   "/users/{id}",
+		// The router documents path parameters automatically.
+		Parameters: []metadata.Parameter{{Name: "id", In: "path", Required: true, Schema: metadata.Schema{Type: "string"}}},
+	}
+	docs.WithParameterRef("UserID")(&m)
+	docs.WithParameterRef("Take")(&m)
+
+	if errs := g.Validate([]openapi.RouteInfo{routeInfo(m)}); len(errs) != 0 {
+		t.Fatalf("expected no validation errors, got %v", errs)
+	}
+
+	spec := g.Generate([]openapi.RouteInfo{routeInfo(m)})
+	data, err := json.Marshal(spec.Paths["/users/{id}"].Get.Parameters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `[{"$ref":"#/components/parameters/UserID"},{"$ref":"#/components/parameters/Take"}]` {
+		t.Fatalf("unexpected parameters %s", data)
+	}
+
+	component, ok := spec.Components.Parameters["UserID"]
+	if !ok || component.Name != "id" || component.In != "path" || component.Schema.Type != "integer" {
+		t.Fatalf("expected the UserID component, got %+v", spec.Components.Parameters)
+	}
+
+	docs.WithParameterRef("Missing")(&m)
+	expectSingleError(t, g.Validate([]openapi.RouteInfo{routeInfo(m)}), `parameter component "Missing"`)
+}
+
+type OrderInput struct {
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+}
+
+func TestRequestBodyComponents(t *testing.T) {
+	g := newTestGenerator()
+	g.WithRequestBodyComponent("OrderInput", metadata.RequestBody{
+		Description: "The order to place",
+		Required:    true,
+		Content: map[string]metadata.MediaType{
+			"application/json": {Schema: docs.SchemaFromType(reflect.TypeOf(OrderInput{}))},
+		},
+	})
+
+	var routes []openapi.RouteInfo
+	for _, method := range []string{"POST", "PUT"} {
+		m := metadata.RouteMetadata{Method: method, Path: "/orders"}
+		docs.WithRequestBodyRef("OrderInput")(&m)
+		routes = append(routes, routeInfo(m))
+	}
+	if errs := g.Validate(routes); len(errs) != 0 {
+		t.Fatalf("expected no validation errors, got %v", errs)
+	}
+
+	spec := g.Generate(routes)
+	for _, op := range []*openapi.Operation{spec.Paths["/orders"].Post, spec.Paths["/orders"].Put} {
+		data, err := json.Marshal(op.RequestBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != `{"$ref":"#/components/requestBodies/OrderInput"}` {
+			t.Fatalf("expected a shared reference, got %s", data)
+		}
+	}
+
+	if len(spec.Components.RequestBodies) != 1 {
+		t.Fatalf("expected the body to be defined once, got %v", keys(spec.Components.RequestBodies))
+	}
+	body := spec.Components.RequestBodies["OrderInput"]
+	ref := body.Content["application/json"].SchemaRef
+	if !body.Required || ref == nil || ref.Ref != "#/components/schemas/OrderInput" {
+		t.Fatalf("expected the component to reference the OrderInput schema, got %+v", body)
+	}
+	if _, ok := spec.Components.Schemas["OrderInput"]; !ok {
+		t.Fatalf("expected the OrderInput schema to be collected, got %v", keys(spec.Components.Schemas))
+	}
+
+	m := metadata.RouteMetadata{Method: "PATCH", Path: "/orders"}
+	docs.WithRequestBodyRef("Missing")(&m)
+	expectSingleError(t, g.Validate([]openapi.RouteInfo{routeInfo(m)}), `request body component "Missing"`)
+}
+
+type PlainAddress struct {
+	StreetName string
+	ZipCode    string `json:"zip"`
+}
+
+type PlainCustomer struct {
+	CustomerID  int `validate:"required"`
+	DisplayName string
+	HomeAddress PlainAddress
+}
+
+func TestPropertyNaming(t *testing.T) {
+	g := newTestGenerator()
+	g.WithPropertyNaming(openapi.SnakeCase)
+
+	m := metadata.RouteMetadata{Method: "GET", Path: "/customers"}
+	docs.WithJSONResponse[PlainCustomer](200, "Customer")(&m)
+	spec := g.Generate([]openapi.RouteInfo{routeInfo(m)})
+
+	customer := spec.Components.Schemas["PlainCustomer"]
+	if got := sortedKeys(customer.Properties); strings.Join(got, ",") != "customer_id,display_name,home_address" {
+		t.Fatalf("expected snake_case properties, got %v", got)
+	}
+	if len(customer.Required) != 1 || customer.Required[0] != "customer_id" {
+		t.Fatalf("expected required to be renamed, got %v", customer.Required)
+	}
+	example, _ := customer.Example.(map[string]interface{})
+	address, _ := example["home_address"].(map[string]interface{})
+	if _, ok := example["customer_id"]; !ok || address == nil {
+		t.Fatalf("expected example keys to be renamed, got %v", customer.Example)
+	}
+	if _, ok := address["street_name"]; !ok {
+		t.Fatalf("expected nested example keys to be renamed, got %v", address)
+	}
+
+	if got := sortedKeys(spec.Components.Schemas["PlainAddress"].Properties); strings.Join(got, ",") != "street_name,zip" {
+		t.Fatalf("expected tagged fields to keep their name, got %v", got)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"CreatedAt":  "created_at",
+		"Name":       "name",
+		"Address2":   "address2",
+	} {
+		if got := openapi.SnakeCase(in); got != want {
+			t.Fatalf("SnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+type ShippingAddress struct {
+	City string `json:"city"`
+}
+
+type Shipment struct {
+	Origin      ShippingAddress  `json:"origin"`
+	Destination *ShippingAddress `json:"destination"`
+}
+
+func TestNullableStructPointerRef(t *testing.T) {
+	m := metadata.RouteMetadata{Method: "GET", Path: "/shipments"}
+	docs.WithJSONResponse[Shipment](200, "Shipment")(&m)
+
+	tests := []struct {
+		version     string
+		destination string
+	}{
+		{"3.0.0", `{"allOf":[{"$ref":"#/components/schemas/ShippingAddress"}],"nullable":true}`},
+		{"3.1.0", `{"anyOf":[{"$ref":"#/components/schemas/ShippingAddress"},{"type":"null"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			g := newTestGenerator()
+			g.WithOpenAPIVersion(tt.version)
+			spec := g.Generate([]openapi.RouteInfo{routeInfo(m)})
+
+			if _, ok := spec.Components.Schemas["ShippingAddress"]; !ok {
+				t.Fatal("expected the nested struct to be a component")
+			}
+			shipment := spec.Components.Schemas["Shipment"]
+			data, err := json.Marshal(shipment.Properties["destination"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.destination {
+				t.Fatalf("expected %s, got %s", tt.destination, data)
+			}
+			if origin := shipment.Properties["origin"]; origin.Nullable || origin.Type != "object" {
+				t.Fatalf("expected the non-pointer field to stay inline and non-nullable, got %+v", origin)
+			}
+		})
+	}
+}
+
+func TestSchemaFromTypePointerFieldNullable(t *testing.T) {
+	schema := openapi.SchemaFromType(reflect.TypeOf(Shipment{}))
+	if !schema.Properties["destination"].Nullable {
+		t.Fatal("expected the pointer field to be nullable")
+	}
+	if schema.Properties["origin"].Nullable {
+		t.Fatal("expected the value field not to be nullable")
+	}
+}
+
+func TestGenerateJSONSchema(t *testing.T) {
+	g := newTestGenerator()
+	m := metadata.RouteMetadata{Method: "GET", Path: "/shipments"}
+	docs.WithJSONResponse[Shipment](200, "Shipment")(&m)
+	data, err := g.GenerateJSONSchema([]openapi.RouteInfo{routeInfo(m)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Schema string                                `json:"$schema"`
+		Defs   map[string]map[string]json.RawMessage `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Schema != "https://json-schema.org/draft/2020-12/schema" {
+		t.Fatalf("expected the draft 2020-12 dialect, got %q", doc.Schema)
+	}
+	if got := sortedKeys(doc.Defs); strings.Join(got, ",") != "Shipment,ShippingAddress" {
+		t.Fatalf("expected both types under $defs, got %v", got)
+	}
+
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(doc.Defs["Shipment"]["properties"], &properties); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"origin":      `{"$ref":"#/$defs/ShippingAddress"}`,
+		"destination": `{"anyOf":[{"$ref":"#/$defs/ShippingAddress"},{"type":"null"}]}`,
+	} {
+		var got, expected interface{}
+		json.Unmarshal(properties[name], &got)
+		json.Unmarshal([]byte(want), &expected)
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("expected %s to be %s, got %s", name, want, properties[name])
+		}
+	}
+	if _, ok := doc.Defs["ShippingAddress"]["examples"]; !ok {
+		t.Fatalf("expected example to become examples, got %s", data)
+	}
+
+	g.Generate(nil)
+	if data, err := g.GenerateJSONSchema([]openapi.RouteInfo{routeInfo(m)}); err != nil || !strings.Contains(string(data), `"Shipment"`) {
+		t.Fatalf("expected the definitions not to depend on the last Generate call, got %s, %v", data, err)
+	}
+}
+
+func TestAPIKeyLocations(t *testing.T) {
+	g := newTestGenerator()
+	g.WithAPIKeyQuery("queryKey", "Key in the query", "api_key")
+	g.WithAPIKeyCookie("cookieKey", "Key in a cookie", "session")
+	schemes := g.Generate(nil).Components.SecuritySchemes
+
+	for name, want := range map[string]openapi.SecurityScheme{
+		"queryKey":  {Type: "apiKey", Description: "Key in the query", In: "query", Name: "api_key"},
+		"cookieKey": {Type: "apiKey", Description: "Key in a cookie", In: "cookie", Name: "session"},
+	} {
+		if got := schemes[name]; !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected %s to be %+v, got %+v", name, want, got)
+		}
+	}
+}
+
+func TestPagedJSONResponse(t *testing.T) {
+	m := metadata.RouteMetadata{Method: "GET", Path: "/addresses"}
+	docs.WithPagedJSONResponse[ShippingAddress](200, "A page of addresses")(&m)
+
+	spec := newTestGenerator().Generate([]openapi.RouteInfo{routeInfo(m)})
+
+	if _, ok := spec.Components.Schemas["ShippingAddress"]; !ok {
+		t.Fatal("expected the item type to be a component")
+	}
+	envelope, ok := spec.Components.Schemas["PagedShippingAddress"]
+	if !ok {
+		t.Fatalf("expected the envelope to be a component, got %v", spec.Components.Schemas)
+	}
+	if envelope.Type != "object" {
+		t.Fatalf("expected an object envelope, got %q", envelope.Type)
+	}
+	data := envelope.Properties["data"]
+	if data.Type != "array" || data.Items == nil || data.Items.Properties["city"].Type != "string" {
+		t.Fatalf("expected data to be an array of addresses, got %+v", data)
+	}
+	if typ := envelope.Properties["nextCursor"].Type; typ != "string" {
+		t.Fatalf("expected nextCursor to be a string, got %q", typ)
+	}
+	if typ := envelope.Properties["hasMore"].Type; typ != "boolean" {
+		t.Fatalf("expected hasMore to be a boolean, got %q", typ)
+	}
+	if !reflect.DeepEqual(envelope.Required, []string{"data", "hasMore"}) {
+		t.Fatalf("expected data and hasMore to be required, got %v", envelope.Required)
+	}
+
+	response := spec.Paths["/addresses"].Get.Responses["200"]
+	if ref := response.Content["application/json"].SchemaRef; ref == nil || ref.Ref != "#/components/schemas/PagedShippingAddress" {
+		t.Fatalf("expected the response to reference the envelope, got %+v", ref)
+	}
+}
+
+func TestPagedJSONResponseFollowsSchemaNamer(t *testing.T) {
+	m := metadata.RouteMetadata{Method: "GET", Path: "/addresses"}
+	docs.WithPagedJSONResponse[ShippingAddress](200, "A page of addresses")(&m)
+
+	g := newTestGenerator()
+	g.WithSchemaNamer(func(t reflect.Type) string {
+		if t == reflect.TypeOf(ShippingAddress{}) {
+			return "Address"
+		}
+		return ""
+	})
+	spec := g.Generate([]openapi.RouteInfo{routeInfo(m)})
+
+	if _, ok := spec.Components.Schemas["PagedAddress"]; !ok {
+		t.Fatalf("expected the envelope to follow the item's name, got %v", spec.Components.Schemas)
+	}
+	if _, ok := spec.Components.Schemas["PagedShippingAddress"]; ok {
+		t.Fatal("expected no envelope under the item's Go name")
+	}
+	response := spec.Paths["/addresses"].Get.Responses["200"]
+	if ref := response.Content["application/json"].SchemaRef; ref == nil || ref.Ref != "#/components/schemas/PagedAddress" {
+		t.Fatalf("expected the response to reference the renamed envelope, got %+v", ref)
+	}
+}
+
+type TagSet struct {
+	Tags   []string `json:"tags" validate:"min=1,max=10,unique"`
+	Scores []int    `json:"scores" validate:"max=3,items.min=0"`
+}
+
+func TestArrayItemConstraints(t *testing.T) {
+	m := metadata.RouteMetadata{Method: "GET", Path: "/tags"}
+	docs.WithJSONResponse[TagSet](200, "Tags")(&m)
+
+	spec := newTestGenerator().Generate([]openapi.RouteInfo{routeInfo(m)})
+	props := spec.Components.Schemas["TagSet"].Properties
+
+	data, err := json.Marshal(props["tags"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"array","items":{"type":"string","example":"example"},"minItems":1,"maxItems":10,"uniqueItems":true}`
+	if string(data) != want {
+		t.Fatalf("expected %s, got %s", want, data)
+	}
+
+	scores := props["scores"]
+	if scores.MinItems != nil || scores.MaxItems == nil || *scores.MaxItems != 3 || scores.UniqueItems {
+		t.Fatalf("expected at most 3 scores, got %+v", scores)
+	}
+	if scores.Items.Minimum == nil || *scores.Items.Minimum != 0 {
+		t.Fatalf("expected the item rule to stay on the items, got %+v", scores.Items)
+	}
+}
+
+type Profile struct {
+	Name     string `json:"name"`
+	Nickname string `json:"nickname" openapi:"deprecated"`
+}
+
+func TestDeprecatedProperty(t *testing.T) {
+	m := metadata.RouteMetadata{Method: "GET", Path: "/profile"}
+	docs.WithJSONResponse[Profile](200, "Profile")(&m)
+
+	spec := newTestGenerator().Generate([]openapi.RouteInfo{routeInfo(m)})
+	data, err := json.Marshal(spec.Components.Schemas["Profile"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.Properties["nickname"]["deprecated"] != true {
+		t.Fatalf("expected nickname to be deprecated, got %s", data)
+	}
+	if _, ok := schema.Properties["name"]["deprecated"]; ok {
+		t.Fatalf("expected name not to be deprecated, got %s", data)
+	}
+
+	if !openapi.SchemaFromType(reflect.TypeOf(Profile{})).Properties["nickname"].Deprecated {
+		t.Fatal("expected SchemaFromType to read the deprecated option")
+	}
+}
+
+type Invoice struct {
+	_      struct{} `title:"Invoice" description:"A bill sent to a customer"`
+	Number string   `json:"number" title:"Invoice number" description:"Sequential number of the invoice"`
+}
+
+func TestSchemaTitleAndDescriptionTags(t *testing.T) {
+	m := metadata.RouteMetadata{Method: "GET", Path: "/invoices/{id}"}
+	docs.WithJSONResponse[Invoice](200, "Invoice")(&m)
+
+	spec := newTestGenerator().Generate([]openapi.RouteInfo{routeInfo(m)})
+	schema := spec.Components.Schemas["Invoice"]
+	if schema.Title != "Invoice" || schema.Description != "A bill sent to a customer" {
+		t.Fatalf("expected the component title and description, got %q and %q", schema.Title, schema.Description)
+	}
+	number := schema.Properties["number"]
+	if number.Title != "Invoice number" || number.Description != "Sequential number of the invoice" {
+		t.Fatalf("expected the property title and description, got %q and %q", number.Title, number.Description)
+	}
+
+	// The builder of the openapi package reads the same tags
+	direct := openapi.SchemaFromType(reflect.TypeOf(Invoice{}))
+	if direct.Title != "Invoice" || direct.Properties["number"].Description != "Sequential number of the invoice" {
+		t.Fatalf("expected SchemaFromType to read the tags, got %+v", direct)
+	}
+}
+
+type Parcel struct {
+	_         struct{}      `title:"Parcel" description:"A parcel in a shipment"`
+	ID        string        `json:"id" validate:"required,min=3"`
+	Weight    *float64      `json:"weight" validate:"max=30"`
+	Labels    []string      `json:"labels" validate:"max=5,items.min=1"`
+	Handling  time.Duration `json:"handling"`
+	ShippedAt time.Time     `json:"shippedAt" description:"When the parcel left"`
+	Legacy    string        `json:"legacy" openapi:"deprecated"`
+	Sender    Shipment      `json:"sender"`
+	History   []Shipment    `json:"history"`
+}
+
+func TestSchemaFromTypeEntryPointsAgree(t *testing.T) {
+	typ := reflect.TypeOf(Parcel{})
+	viaDocs := openapi.SchemaFromMetadataSchema(docs.SchemaFromType(typ))
+	viaOpenAPI := openapi.SchemaFromType(typ)
+
+	// The only difference is that openapi references the items of arrays
+	// of structs instead of inlining them
+	if ref := viaOpenAPI.Properties["history"].Items.Ref; ref != "#/components/schemas/Shipment" {
+		t.Fatalf("expected the history items to reference Shipment, got %q", ref)
+	}
+	if viaDocs.Properties["history"].Items.Properties == nil {
+		t.Fatal("expected docs to inline the history items")
+	}
+	delete(viaDocs.Properties, "history")
+	delete(viaOpenAPI.Properties, "history")
+
+	a, err := json.Marshal(viaDocs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(viaOpenAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(a) != string(b) {
+		t.Fatalf("expected identical schemas, got\n%s\n%s", a, b)
+	}
+}
+
+type Member struct {
+	Email    string  `json:"email"`
+	Phone    string  `json:"phone,omitempty"`
+	Role     string  `json:"role,omitempty" validate:"required"`
+	Nickname *string `json:"nickname" example:"Ace"`
+}
+
+func TestExamplesWithoutOptionalFields(t *testing.T) {
+	m := metadata.RouteMetadata{Method: "GET", Path: "/contacts/{id}"}
+	docs.WithJSONResponse[Member](200, "Contact")(&m)
+
+	exampleKeys := func(g *openapi.Generator) []string {
+		spec := g.Generate([]openapi.RouteInfo{routeInfo(m)})
+		example, ok := spec.Components.Schemas["Member"].Example.(map[string]interface{})
+		if !ok {
+			t.Fatalf("expected a generated example, got %#v", spec.Components.Schemas["Member"].Example)
+		}
+		var keys []string
+		for key := range example {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+
+	if keys := exampleKeys(newTestGenerator()); !reflect.DeepEqual(keys, []string{"email", "nickname", "phone", "role"}) {
+		t.Fatalf("expected every field by default, got %v", keys)
+	}
+
+	g := newTestGenerator()
+	g.WithExamplesIncludeOptional(false)
+	if keys := exampleKeys(g); !reflect.DeepEqual(keys, []string{"email", "role"}) {
+		t.Fatalf("expected only the non-optional fields, got %v", keys)
+	}
+}
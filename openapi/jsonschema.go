@@ -0,0 +1,116 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// jsonSchemaDialect is the JSON Schema draft emitted by GenerateJSONSchema
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// GenerateJSONSchema creates a JSON Schema (draft 2020-12) document holding
+// the types used by the given routes under "$defs". Struct types
+// nested in one another reference each other's definition with "$ref", and
+// OpenAPI specific keywords are translated: nullable becomes a "null" type
+// and example becomes examples.
+func (g *Generator) GenerateJSONSchema(routes []RouteInfo) ([]byte, error) {
+	gen := g.buildSchemas(routes)
+
+	defs := make(map[string]interface{}, len(gen.schemas))
+	for name, schema := range gen.schemas {
+		data, err := json.Marshal(gen.referenceNestedSchemas(schema))
+		if err != nil {
+			return nil, err
+		}
+		var def map[string]interface{}
+		if err := json.Unmarshal(data, &def); err != nil {
+			return nil, err
+		}
+		defs[name] = toJSONSchema(def)
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"$schema": jsonSchemaDialect,
+		"$defs":   defs,
+	}, "", "  ")
+}
+
+// referenceNestedSchemas replaces the struct types nested in a schema, in its
+// properties and array items, with references to their collected schema
+func (g *generation) referenceNestedSchemas(schema Schema) Schema {
+	if schema.Items != nil {
+		items := g.nestedSchema(*schema.Items)
+		schema.Items = &items
+	}
+	if schema.Properties != nil {
+		properties := make(map[string]Schema, len(schema.Properties))
+		for name, prop := range schema.Properties {
+			properties[name] = g.nestedSchema(prop)
+		}
+		schema.Properties = properties
+	}
+	return schema
+}
+
+// nestedSchema returns a reference to the collected schema of a nested struct
+// type, keeping its nullability, or the schema with its own nested types
+// referenced
+func (g *generation) nestedSchema(schema Schema) Schema {
+	name := g.generateSchemaName(schema)
+	if schema.Type != "object" || schema.Properties == nil || name == "" || g.schemas[name].Type == "" {
+		return g.referenceNestedSchemas(schema)
+	}
+
+	ref := Schema{Ref: "#/components/schemas/" + name}
+	if !schema.Nullable {
+		ref.Description = schema.Description
+		return ref
+	}
+	return Schema{
+		Description: schema.Description,
+		AllOf:       []Schema{ref},
+		Nullable:    true,
+	}
+}
+
+// toJSONSchema translates a decoded OpenAPI schema into its JSON Schema form
+func toJSONSchema(schema map[string]interface{}) map[string]interface{} {
+	for key, value := range schema {
+		switch key {
+		case "items", "additionalProperties":
+			if sub, ok := value.(map[string]interface{}); ok {
+				schema[key] = toJSONSchema(sub)
+			}
+		case "properties":
+			for name, prop := range value.(map[string]interface{}) {
+				if sub, ok := prop.(map[string]interface{}); ok {
+					value.(map[string]interface{})[name] = toJSONSchema(sub)
+				}
+			}
+		case "allOf", "anyOf", "oneOf":
+			for i, item := range value.([]interface{}) {
+				if sub, ok := item.(map[string]interface{}); ok {
+					value.([]interface{})[i] = toJSONSchema(sub)
+				}
+			}
+		}
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		schema["$ref"] = "#/$defs/" + strings.TrimPrefix(ref, "#/components/schemas/")
+	}
+	if example, ok := schema["example"]; ok {
+		schema["examples"] = []interface{}{example}
+		delete(schema, "example")
+	}
+	if nullable, _ := schema["nullable"].(bool); nullable {
+		delete(schema, "nullable")
+		if typ, ok := schema["type"].(string); ok {
+			schema["type"] = []interface{}{typ, "null"}
+		} else if allOf, ok := schema["allOf"].([]interface{}); ok && len(allOf) == 1 {
+			delete(schema, "allOf")
+			schema["anyOf"] = []interface{}{allOf[0], map[string]interface{}{"type": "null"}}
+		}
+	}
+	return schema
+}
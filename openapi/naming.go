@@ -0,0 +1,281 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/joakimcarlsson/go-router/metadata"
+)
+
+// WithSchemaNamer sets a function that names the component schemas generated
+// from Go types. The returned name is used both as the key in components/schemas
+// and as the target of every $ref pointing to the schema. Returning an empty
+// string keeps the default name.
+func (g *Generator) WithSchemaNamer(fn func(reflect.Type) string) {
+	g.schemaNamer = fn
+}
+
+// WithPropertyNaming sets a function that names the schema properties of
+// struct fields without a json tag, whose names would otherwise be the Go
+// field names. Use it when the JSON encoder applies a naming convention, such
+// as SnakeCase, so the documented property names, required lists and
+// examples match what is sent on the wire. Fields with a json tag keep the
+// tag's name.
+func (g *Generator) WithPropertyNaming(fn func(string) string) {
+	g.propertyNaming = fn
+}
+
+// SnakeCase converts a Go identifier to snake_case, keeping acronyms
+// together: "UserID" becomes "user_id" and "HTTPServer" becomes "http_server".
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					b.WriteByte('_')
+				}
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// schemaNamer assigns component names to the schemas generated from Go types.
+// Names are resolved against a registry scoped to a single Generate call, so
+// they only depend on the routes being documented and not on other APIs
+// documented in the same process.
+type schemaNamer struct {
+	registry *metadata.TypeRegistry
+	custom   func(reflect.Type) string
+	property func(string) string
+	// omitOptional leaves optional fields out of generated examples
+	omitOptional bool
+}
+
+// nameSchemas returns copies of the routes and request body components
+// whose schemas carry the names assigned by this generator.
+func (g *Generator) nameSchemas(routes []RouteInfo) ([]RouteInfo, map[string]metadata.RequestBody) {
+	namer := &schemaNamer{
+		registry: metadata.NewTypeRegistry(),
+		custom:   g.schemaNamer,
+		property: g.propertyNaming,
+
+		omitOptional: g.omitOptionalExamples,
+	}
+
+	// Register every type first so that collisions are resolved
+	// before any name is handed out.
+	for _, rb := range g.requestBodies {
+		for _, mediaType := range rb.Content {
+			namer.register(mediaType.Schema)
+		}
+	}
+	for _, route := range routes {
+		if rb := route.RequestBody(); rb != nil {
+			for _, mediaType := range rb.Content {
+				namer.register(mediaType.Schema)
+			}
+		}
+		for _, response := range route.Responses() {
+			for _, mediaType := range response.Content {
+				namer.register(mediaType.Schema)
+			}
+		}
+	}
+
+	named := make([]RouteInfo, len(routes))
+	for i, route := range routes {
+		m := metadataFromRouteInfo(route)
+
+		if rb := m.RequestBody; rb != nil {
+			renamed := *rb
+			renamed.Content = namer.renameContent(rb.Content)
+			m.RequestBody = &renamed
+		}
+
+		if m.Responses != nil {
+			responses := make(map[string]metadata.Response, len(m.Responses))
+			for code, response := range m.Responses {
+				response.Content = namer.renameContent(response.Content)
+				responses[code] = response
+			}
+			m.Responses = responses
+		}
+
+		named[i] = RouteInfoFromMetadata(m)
+	}
+
+	requestBodies := make(map[string]metadata.RequestBody, len(g.requestBodies))
+	for name, rb := range g.requestBodies {
+		rb.Content = namer.renameContent(rb.Content)
+		requestBodies[name] = rb
+	}
+	return named, requestBodies
+}
+
+// register adds the Go types of a schema and its nested schemas to the registry.
+func (n *schemaNamer) register(s metadata.Schema) {
+	// Page envelopes are named after their items
+	if s.GoType != nil && !metadata.IsPageType(s.GoType) {
+		n.registry.Register(s.GoType)
+	}
+	if s.Items != nil {
+		n.register(*s.Items)
+	}
+	for _, prop := range s.Properties {
+		n.register(prop)
+	}
+}
+
+// renameContent renames the schemas of a content map.
+func (n *schemaNamer) renameContent(content map[string]metadata.MediaType) map[string]metadata.MediaType {
+	if content == nil {
+		return nil
+	}
+	renamed := make(map[string]metadata.MediaType, len(content))
+	for contentType, mediaType := range content {
+		mediaType.Schema = n.renameSchema(mediaType.Schema)
+		renamed[contentType] = mediaType
+	}
+	return renamed
+}
+
+// renameSchema returns a deep copy of the schema with the type names of
+// all schemas generated from Go types replaced by their assigned names.
+func (n *schemaNamer) renameSchema(s metadata.Schema) metadata.Schema {
+	page := metadata.IsPageType(s.GoType)
+	if s.GoType != nil && !page {
+		if name := n.registry.Name(s.GoType); name != "" {
+			s.TypeName = name
+		}
+		if n.custom != nil {
+			if name := n.custom(s.GoType); name != "" {
+				s.TypeName = name
+			}
+		}
+	}
+
+	if s.Items != nil {
+		items := n.renameSchema(*s.Items)
+		s.Items = &items
+		if s.Type == "array" && items.TypeName != "" {
+			s.TypeName = "[]" + items.TypeName
+		}
+	}
+
+	if s.Properties != nil {
+		if n.omitOptional && s.GoType != nil {
+			s.Example = withoutOptionalFields(s.Example, s.GoType)
+		}
+		untagged := n.untaggedFields(s.GoType)
+		properties := make(map[string]metadata.Schema, len(s.Properties))
+		for name, prop := range s.Properties {
+			properties[n.propertyName(name, untagged)] = n.renameSchema(prop)
+		}
+		s.Properties = properties
+
+		if n.property != nil {
+			required := make([]string, len(s.Required))
+			for i, name := range s.Required {
+				required[i] = n.propertyName(name, untagged)
+			}
+			s.Required = required
+			s.Example = n.renameExample(s.Example, s.GoType)
+		}
+	}
+
+	if page {
+		s.TypeName = pageName(s)
+	}
+	return s
+}
+
+// pageName returns the name of a renamed page envelope, "Paged" followed by
+// the name of its items. Pages of items without a component are not named,
+// so they stay inline.
+func pageName(s metadata.Schema) string {
+	if items := s.Properties["data"].Items; items != nil && items.Type == "object" && items.TypeName != "" {
+		return "Paged" + items.TypeName
+	}
+	return ""
+}
+
+// untaggedFields returns the names of the exported fields of a struct type
+// that have no json tag name, when a property naming function is set.
+func (n *schemaNamer) untaggedFields(t reflect.Type) map[string]bool {
+	if n.property == nil || t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	untagged := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.IsExported() && strings.Split(field.Tag.Get("json"), ",")[0] == "" {
+			untagged[field.Name] = true
+		}
+	}
+	return untagged
+}
+
+// propertyName returns the documented name of a property.
+func (n *schemaNamer) propertyName(name string, untagged map[string]bool) string {
+	if untagged[name] {
+		return n.property(name)
+	}
+	return name
+}
+
+// renameExample renames the keys of a generated example for type t and its
+// nested struct values the same way as the schema properties.
+func (n *schemaNamer) renameExample(example interface{}, t reflect.Type) interface{} {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return example
+	}
+
+	switch v := example.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct {
+			return example
+		}
+		untagged := n.untaggedFields(t)
+		renamed := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			if field, ok := t.FieldByName(key); ok && untagged[key] {
+				renamed[n.property(key)] = n.renameExample(value, field.Type)
+				continue
+			}
+			renamed[key] = n.renameExample(value, fieldTypeByJSONName(t, key))
+		}
+		return renamed
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return example
+		}
+		renamed := make([]interface{}, len(v))
+		for i, value := range v {
+			renamed[i] = n.renameExample(value, t.Elem())
+		}
+		return renamed
+	}
+	return example
+}
+
+// fieldTypeByJSONName returns the type of the struct field whose json tag name is name.
+func fieldTypeByJSONName(t reflect.Type, name string) reflect.Type {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if strings.Split(field.Tag.Get("json"), ",")[0] == name {
+			return field.Type
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package openapi
+
+// withNullTypes rewrites the nullable schemas of the specification for
+// OpenAPI 3.1, which dropped the nullable keyword in favor of adding "null"
+// to the type, such as type: [string, "null"]
+func withNullTypes(spec *Spec) {
+	if spec.Components != nil {
+		for name, schema := range spec.Components.Schemas {
+			spec.Components.Schemas[name] = nullTypes(schema)
+		}
+		for name, param := range spec.Components.Parameters {
+			param.Schema = nullTypes(param.Schema)
+			spec.Components.Parameters[name] = param
+		}
+		for name, body := range spec.Components.RequestBodies {
+			body.Content = contentNullTypes(body.Content)
+			spec.Components.RequestBodies[name] = body
+		}
+	}
+
+	for _, item := range spec.Paths {
+		for _, op := range []*Operation{item.Get, item.Post, item.Put, item.Delete, item.Patch, item.Options, item.Head, item.Trace} {
+			if op == nil {
+				continue
+			}
+			for i := range op.Parameters {
+				op.Parameters[i].Schema = nullTypes(op.Parameters[i].Schema)
+			}
+			if op.RequestBody != nil {
+				op.RequestBody.Content = contentNullTypes(op.RequestBody.Content)
+			}
+			for code, response := range op.Responses {
+				response.Content = contentNullTypes(response.Content)
+				for name, header := range response.Headers {
+					header.Schema = nullTypes(header.Schema)
+					response.Headers[name] = header
+				}
+				op.Responses[code] = response
+			}
+		}
+	}
+}
+
+// contentNullTypes applies nullTypes to the schemas of request or response content
+func contentNullTypes(content map[string]MediaType) map[string]MediaType {
+	for contentType, media := range content {
+		media.Schema = nullTypes(media.Schema)
+		content[contentType] = media
+	}
+	return content
+}
+
+// nullTypes replaces nullable with a "null" type in the schema and the
+// schemas nested in it. A nullable allOf wrapping a reference becomes an
+// anyOf of the reference and the "null" type.
+func nullTypes(s Schema) Schema {
+	if s.Nullable {
+		s.Nullable = false
+		switch {
+		case s.Type != "":
+			s.nullType = true
+		case len(s.AllOf) == 1:
+			s.AnyOf = []Schema{s.AllOf[0], {Type: "null"}}
+			s.AllOf = nil
+		}
+	}
+
+	if s.Items != nil {
+		items := nullTypes(*s.Items)
+		s.Items = &items
+	}
+	if s.AdditionalProperties != nil {
+		additional := nullTypes(*s.AdditionalProperties)
+		s.AdditionalProperties = &additional
+	}
+	if s.Properties != nil {
+		properties := make(map[string]Schema, len(s.Properties))
+		for name, prop := range s.Properties {
+			properties[name] = nullTypes(prop)
+		}
+		s.Properties = properties
+	}
+	for _, list := range []*[]Schema{&s.AllOf, &s.AnyOf, &s.OneOf} {
+		if *list == nil {
+			continue
+		}
+		schemas := make([]Schema, len(*list))
+		for i, schema := range *list {
+			schemas[i] = nullTypes(schema)
+		}
+		*list = schemas
+	}
+	return s
+}
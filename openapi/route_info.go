@@ -17,6 +17,59 @@ type RouteInfo interface {
 	IsDeprecated() bool
 }
 
+// RouteExtensions is implemented by a RouteInfo whose operation carries
+// vendor extensions (x-*). It is a separate interface so that RouteInfo
+// implementations without extensions don't need the method.
+type RouteExtensions interface {
+	Extensions() map[string]interface{}
+}
+
+// routeServers returns the servers overriding the API's servers for a route,
+// or nil if it doesn't implement RouteServers.
+func routeServers(route RouteInfo) []metadata.Server {
+	if r, ok := route.(RouteServers); ok {
+		return r.Servers()
+	}
+	return nil
+}
+
+// routeExtensions returns the vendor extensions of a route, or nil if it
+// doesn't implement RouteExtensions.
+func routeExtensions(route RouteInfo) map[string]interface{} {
+	if r, ok := route.(RouteExtensions); ok {
+		return r.Extensions()
+	}
+	return nil
+}
+
+// RouteServers is implemented by a RouteInfo whose operation is served from
+// servers other than the API's. It is a separate interface so that RouteInfo
+// implementations without servers don't need the method.
+type RouteServers interface {
+	Servers() []metadata.Server
+}
+
+// RouteExclusion is implemented by a RouteInfo that can be hidden from the
+// generated documentation, as routes registered with docs.WithExcludeFromDocs
+// are. It is a separate interface so that other RouteInfo implementations
+// don't need the method.
+type RouteExclusion interface {
+	ExcludeFromDocs() bool
+}
+
+// documentedRoutes returns the routes that are not excluded from the
+// documentation through RouteExclusion.
+func documentedRoutes(routes []RouteInfo) []RouteInfo {
+	documented := make([]RouteInfo, 0, len(routes))
+	for _, route := range routes {
+		if r, ok := route.(RouteExclusion); ok && r.ExcludeFromDocs() {
+			continue
+		}
+		documented = append(documented, route)
+	}
+	return documented
+}
+
 // RouteMetadataAdapter adapts the RouteMetadata structure to the RouteInfo interface
 type RouteMetadataAdapter struct {
 	Metadata metadata.RouteMetadata
@@ -77,6 +130,21 @@ func (a *RouteMetadataAdapter) IsDeprecated() bool {
 	return a.Metadata.Deprecated
 }
 
+// ExcludeFromDocs returns whether the route is hidden from the documentation
+func (a *RouteMetadataAdapter) ExcludeFromDocs() bool {
+	return a.Metadata.ExcludeFromDocs
+}
+
+// Servers returns the servers overriding the API's servers for the route
+func (a *RouteMetadataAdapter) Servers() []metadata.Server {
+	return a.Metadata.Servers
+}
+
+// Extensions returns the vendor extensions of the route
+func (a *RouteMetadataAdapter) Extensions() map[string]interface{} {
+	return a.Metadata.Extensions
+}
+
 // RouteInfoList is a collection of RouteInfo objects
 type RouteInfoList []RouteInfo
 
@@ -86,3 +154,23 @@ func RouteInfoFromMetadata(metadata metadata.RouteMetadata) RouteInfo {
 		Metadata: metadata,
 	}
 }
+
+// metadataFromRouteInfo copies the information exposed by a RouteInfo
+// back into a RouteMetadata value.
+func metadataFromRouteInfo(route RouteInfo) metadata.RouteMetadata {
+	return metadata.RouteMetadata{
+		Method:      route.Method(),
+		Path:        route.Path(),
+		OperationID: route.OperationID(),
+		Summary:     route.Summary(),
+		Description: route.Description(),
+		Tags:        route.Tags(),
+		Deprecated:  route.IsDeprecated(),
+		Parameters:  route.Parameters(),
+		RequestBody: route.RequestBody(),
+		Responses:   route.Responses(),
+		Security:    route.Security(),
+		Servers:     routeServers(route),
+		Extensions:  routeExtensions(route),
+	}
+}
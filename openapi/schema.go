@@ -10,15 +10,21 @@ func SchemaFromMetadataSchema(s metadata.Schema) Schema {
 		Type:                 s.Type,
 		Ref:                  s.Ref,
 		Format:               s.Format,
+		Title:                s.Title,
 		Description:          s.Description,
 		Example:              s.Example,
+		Default:              s.Default,
 		Required:             s.Required,
 		MinLength:            s.MinLength,
 		MaxLength:            s.MaxLength,
 		Minimum:              s.Minimum,
 		Maximum:              s.Maximum,
+		MinItems:             s.MinItems,
+		MaxItems:             s.MaxItems,
+		UniqueItems:          s.UniqueItems,
 		Enum:                 s.Enum,
 		Nullable:             s.Nullable,
+		Deprecated:           s.Deprecated,
 		TypeName:             s.TypeName,
 		Properties:           convertProperties(s.Properties),
 		Items:                convertItems(s.Items),
@@ -26,6 +32,7 @@ func SchemaFromMetadataSchema(s metadata.Schema) Schema {
 		OneOf:                convertSchemaSlice(s.OneOf),
 		AnyOf:                convertSchemaSlice(s.AnyOf),
 		AdditionalProperties: convertAdditionalProperties(s.AdditionalProperties),
+		Extensions:           s.Extensions,
 	}
 }
 
@@ -76,9 +83,22 @@ func ParameterFromMetadataParameter(p metadata.Parameter) Parameter {
 		Description: p.Description,
 		Schema:      SchemaFromMetadataSchema(p.Schema),
 		Example:     p.Example,
+		Examples:    examplesFromMetadata(p.Examples),
 	}
 }
 
+// examplesFromMetadata converts named example values to OpenAPI Example objects
+func examplesFromMetadata(values map[string]interface{}) map[string]Example {
+	if len(values) == 0 {
+		return nil
+	}
+	examples := make(map[string]Example, len(values))
+	for name, value := range values {
+		examples[name] = Example{Value: value}
+	}
+	return examples
+}
+
 // ResponseFromMetadataResponse converts a metadata Response to an OpenAPI Response
 func ResponseFromMetadataResponse(r metadata.Response) Response {
 	content := make(map[string]MediaType)
@@ -199,3 +219,14 @@ func RequestBodyFromMetadataRequestBody(r *metadata.RequestBody) *RequestBody {
 		Content:     content,
 	}
 }
+
+func convertServers(servers []metadata.Server) []Server {
+	if servers == nil {
+		return nil
+	}
+	result := make([]Server, len(servers))
+	for i, s := range servers {
+		result[i] = Server{URL: s.URL, Description: s.Description}
+	}
+	return result
+}
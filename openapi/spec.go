@@ -1,10 +1,10 @@
 package openapi
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 	"reflect"
-	"strconv"
 	"strings"
 
 	"github.com/joakimcarlsson/go-router/metadata"
@@ -35,12 +35,12 @@ type SchemaOrReference struct {
 // MarshalJSON implements the json.Marshaler interface for SchemaOrReference
 func (s SchemaOrReference) MarshalJSON() ([]byte, error) {
 	if s.Reference != nil {
-		return json.Marshal(s.Reference)
+		return marshalJSON(s.Reference)
 	}
 	if s.Schema != nil {
-		return json.Marshal(s.Schema)
+		return marshalJSON(s.Schema)
 	}
-	return json.Marshal(nil)
+	return marshalJSON(nil)
 }
 
 type Info struct {
@@ -98,14 +98,36 @@ type Operation struct {
 	Responses   map[string]Response   `json:"responses"`
 	Security    []SecurityRequirement `json:"security,omitempty"`
 	Deprecated  bool                  `json:"deprecated,omitempty"`
+	// Servers overrides the servers of the API for this operation
+	Servers []Server `json:"servers,omitempty"`
+	// Extensions holds vendor extensions (x-*) serialized inline
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON implements custom JSON marshaling for Operation to inline vendor extensions
+func (o Operation) MarshalJSON() ([]byte, error) {
+	type operation Operation
+	return marshalWithExtensions(operation(o), o.Extensions)
 }
 
 type SecurityRequirement map[string][]string
 
 type RequestBody struct {
 	Description string               `json:"description,omitempty"`
-	Required    bool                 `json:"required,omitempty"`
+	Required    bool                 `json:"required"`
 	Content     map[string]MediaType `json:"content"`
+	// Ref is the location of a request body component; when set the
+	// request body is emitted as a reference
+	Ref string `json:"-"`
+}
+
+// MarshalJSON emits the request body as a reference when Ref is set
+func (r RequestBody) MarshalJSON() ([]byte, error) {
+	if r.Ref != "" {
+		return marshalJSON(Reference{Ref: r.Ref})
+	}
+	type requestBody RequestBody
+	return marshalJSON(requestBody(r))
 }
 
 // MediaType represents a media type object in OpenAPI spec
@@ -118,7 +140,7 @@ type MediaType struct {
 // MarshalJSON implements custom JSON marshaling for MediaType to handle schema references properly
 func (m MediaType) MarshalJSON() ([]byte, error) {
 	if m.SchemaRef != nil {
-		return json.Marshal(struct {
+		return marshalJSON(struct {
 			Schema  *Reference  `json:"schema"`
 			Example interface{} `json:"example,omitempty"`
 		}{
@@ -128,7 +150,7 @@ func (m MediaType) MarshalJSON() ([]byte, error) {
 	}
 
 	// Otherwise marshal as normal
-	return json.Marshal(struct {
+	return marshalJSON(struct {
 		Schema  Schema      `json:"schema"`
 		Example interface{} `json:"example,omitempty"`
 	}{
@@ -138,12 +160,31 @@ func (m MediaType) MarshalJSON() ([]byte, error) {
 }
 
 type Parameter struct {
-	Name        string      `json:"name"`
-	In          string      `json:"in"` // query, path, header, cookie
-	Required    bool        `json:"required,omitempty"`
-	Description string      `json:"description,omitempty"`
-	Schema      Schema      `json:"schema"`
-	Example     interface{} `json:"example,omitempty"`
+	Name        string             `json:"name"`
+	In          string             `json:"in"` // query, path, header, cookie
+	Required    bool               `json:"required,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Schema      Schema             `json:"schema"`
+	Example     interface{}        `json:"example,omitempty"`
+	Examples    map[string]Example `json:"examples,omitempty"`
+	// Ref is the location of a parameter component; when set the
+	// parameter is emitted as a reference
+	Ref string `json:"-"`
+}
+
+// MarshalJSON emits the parameter as a reference when Ref is set
+func (p Parameter) MarshalJSON() ([]byte, error) {
+	if p.Ref != "" {
+		return marshalJSON(Reference{Ref: p.Ref})
+	}
+	type parameter Parameter
+	return marshalJSON(parameter(p))
+}
+
+// Example represents an example object in OpenAPI spec
+type Example struct {
+	Summary string      `json:"summary,omitempty"`
+	Value   interface{} `json:"value"`
 }
 
 // Schema represents an OpenAPI schema
@@ -151,22 +192,69 @@ type Schema struct {
 	Type                 string            `json:"type,omitempty"`
 	Ref                  string            `json:"$ref,omitempty"`
 	Format               string            `json:"format,omitempty"`
+	Title                string            `json:"title,omitempty"`
 	Description          string            `json:"description,omitempty"`
 	Items                *Schema           `json:"items,omitempty"`
 	Properties           map[string]Schema `json:"properties,omitempty"`
 	Example              interface{}       `json:"example,omitempty"`
+	Default              interface{}       `json:"default,omitempty"`
 	Required             []string          `json:"required,omitempty"`
 	MinLength            *int              `json:"minLength,omitempty"`
 	MaxLength            *int              `json:"maxLength,omitempty"`
 	Minimum              *float64          `json:"minimum,omitempty"`
 	Maximum              *float64          `json:"maximum,omitempty"`
+	MinItems             *int              `json:"minItems,omitempty"`
+	MaxItems             *int              `json:"maxItems,omitempty"`
+	UniqueItems          bool              `json:"uniqueItems,omitempty"`
 	Enum                 []interface{}     `json:"enum,omitempty"`
 	AllOf                []Schema          `json:"allOf,omitempty"`
 	OneOf                []Schema          `json:"oneOf,omitempty"`
 	AnyOf                []Schema          `json:"anyOf,omitempty"`
 	Nullable             bool              `json:"nullable,omitempty"`
+	Deprecated           bool              `json:"deprecated,omitempty"`
 	AdditionalProperties *Schema           `json:"additionalProperties,omitempty"`
 	TypeName             string            `json:"-"`
+	// Extensions holds vendor extensions (x-*) serialized inline
+	Extensions map[string]interface{} `json:"-"`
+
+	// nullType adds "null" to the type, as OpenAPI 3.1 expresses nullable
+	nullType bool
+}
+
+// MarshalJSON implements custom JSON marshaling for Schema to inline vendor extensions
+func (s Schema) MarshalJSON() ([]byte, error) {
+	type schema Schema
+	if s.nullType {
+		// The outer type field takes precedence over the embedded one
+		return marshalWithExtensions(struct {
+			Type []string `json:"type"`
+			schema
+		}{[]string{s.Type, "null"}, schema(s)}, s.Extensions)
+	}
+	return marshalWithExtensions(schema(s), s.Extensions)
+}
+
+// marshalWithExtensions marshals v and merges the extensions into the resulting object.
+func marshalWithExtensions(v interface{}, extensions map[string]interface{}) ([]byte, error) {
+	data, err := marshalJSON(v)
+	if err != nil || len(extensions) == 0 {
+		return data, err
+	}
+
+	ext, err := marshalJSON(extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Both values are JSON objects: splice the extension members into the first one
+	if len(data) == 2 {
+		return ext, nil
+	}
+	merged := make([]byte, 0, len(data)+len(ext))
+	merged = append(merged, data[:len(data)-1]...)
+	merged = append(merged, ',')
+	merged = append(merged, ext[1:]...)
+	return merged, nil
 }
 
 type Response struct {
@@ -182,6 +270,8 @@ type Header struct {
 
 type Components struct {
 	Schemas         map[string]Schema         `json:"schemas,omitempty"`
+	Parameters      map[string]Parameter      `json:"parameters,omitempty"`
+	RequestBodies   map[string]RequestBody    `json:"requestBodies,omitempty"`
 	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
 }
 
@@ -217,63 +307,29 @@ type Tag struct {
 	Description string `json:"description,omitempty"`
 }
 
-// SchemaFromType generates an OpenAPI schema from a Go type
+// SchemaFromType generates an OpenAPI schema from a Go type, as described
+// by metadata.SchemaFromType. Unlike in the documentation built from route
+// options, the items of arrays of structs reference the component schema of
+// the struct instead of repeating it inline.
 func SchemaFromType(t reflect.Type) Schema {
-	// Special handling for time.Time
-	if t.String() == "time.Time" {
-		return Schema{
-			Type:     "string",
-			Format:   "date-time",
-			Example:  "2025-02-22T08:36:06.224266+01:00",
-			TypeName: "time.Time",
-		}
-	}
-
-	switch t.Kind() {
-	case reflect.Ptr:
-		return SchemaFromType(t.Elem())
-	case reflect.Struct:
-		properties, required := getStructProperties(t)
-
-		// Register the type and get a collision-free name
-		typeName := metadata.RegisterType(t)
+	return withItemRefs(SchemaFromMetadataSchema(metadata.SchemaFromType(t)))
+}
 
-		schema := Schema{
-			Type:       "object",
-			Properties: properties,
-			TypeName:   typeName,
-		}
-		if len(required) > 0 {
-			schema.Required = required
-		}
-		if example := generateExample(t); example != nil {
-			schema.Example = example
-		}
-		return schema
-	case reflect.Slice, reflect.Array:
-		itemSchema := SchemaFromType(t.Elem())
-		if itemSchema.Type == "object" && itemSchema.TypeName != "" {
-			return Schema{
-				Type: "array",
-				Items: &Schema{
-					Ref: "#/components/schemas/" + metadata.SanitizeSchemaName(itemSchema.TypeName),
-				},
-				TypeName: "[]" + itemSchema.TypeName,
-			}
-		}
-		return Schema{
-			Type:     "array",
-			Items:    &itemSchema,
-			TypeName: "[]" + itemSchema.TypeName,
+// withItemRefs replaces the items of arrays of named objects in a schema and
+// its properties with references to their component schema.
+func withItemRefs(schema Schema) Schema {
+	if schema.Items != nil {
+		if schema.Items.Type == "object" && schema.Items.TypeName != "" {
+			schema.Items = &Schema{Ref: "#/components/schemas/" + metadata.SanitizeSchemaName(schema.Items.TypeName)}
+		} else {
+			items := withItemRefs(*schema.Items)
+			schema.Items = &items
 		}
-	default:
-		schema := Schema{
-			Type:     getGoTypeSchema(t),
-			TypeName: t.Name(),
-		}
-		schema.Example = getExampleValue(t)
-		return schema
 	}
+	for name, prop := range schema.Properties {
+		schema.Properties[name] = withItemRefs(prop)
+	}
+	return schema
 }
 
 // sanitizeSchemaName converts a fully qualified type name to a valid schema name
@@ -285,164 +341,25 @@ func sanitizeSchemaName(name string) string {
 	return name
 }
 
-func getValidationRules(field reflect.StructField) (required bool, minLen, maxLen *int, min *float64) {
-	tag := field.Tag.Get("validate")
-	if tag == "" {
-		return
-	}
-
-	rules := strings.Split(tag, ",")
-	for _, rule := range rules {
-		if rule == "required" {
-			required = true
-			continue
-		}
-
-		if strings.HasPrefix(rule, "min=") {
-			val, err := strconv.Atoi(strings.TrimPrefix(rule, "min="))
-			if err == nil {
-				if field.Type.Kind() == reflect.String {
-					minLen = &val
-				} else {
-					floatVal := float64(val)
-					min = &floatVal
-				}
-			}
-		}
-
-		if strings.HasPrefix(rule, "max=") {
-			val, err := strconv.Atoi(strings.TrimPrefix(rule, "max="))
-			if err == nil && field.Type.Kind() == reflect.String {
-				maxLen = &val
-			}
-		}
-	}
-	return
-}
-
-func getStructProperties(t reflect.Type) (map[string]Schema, []string) {
-	properties := make(map[string]Schema)
-	var required []string
-
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		if !field.IsExported() {
-			continue
-		}
-
-		name := field.Tag.Get("json")
-		if idx := strings.Index(name, ","); idx != -1 {
-			name = name[:idx]
-		}
-		if name == "-" {
-			continue
-		}
-		if name == "" {
-			name = field.Name
-		}
-
-		isRequired, minLen, maxLen, min := getValidationRules(field)
-		if isRequired {
-			required = append(required, name)
-		}
-
-		schema := SchemaFromType(field.Type)
-		schema.MinLength = minLen
-		schema.MaxLength = maxLen
-		schema.Minimum = min
-		properties[name] = schema
-	}
-
-	return properties, required
-}
-
-func getGoTypeSchema(t reflect.Type) string {
-	switch t.Kind() {
-	case reflect.Bool:
-		return "boolean"
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return "integer"
-	case reflect.Float32, reflect.Float64:
-		return "number"
-	case reflect.String:
-		return "string"
-	default:
-		return "object"
-	}
-}
-
-func getExampleValue(t reflect.Type) interface{} {
-	switch t.Kind() {
-	case reflect.Bool:
-		return true
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return 42
-	case reflect.Float32, reflect.Float64:
-		return 3.14
-	case reflect.String:
-		return "example"
-	default:
-		return nil
-	}
-}
-
-func generateExample(t reflect.Type) interface{} {
-	if t.Kind() != reflect.Struct {
-		return nil
-	}
-
-	example := make(map[string]interface{})
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-
-		// Skip unexported fields
-		if !field.IsExported() {
-			continue
-		}
-
-		// Get JSON tag name or field name
-		name := field.Tag.Get("json")
-		if idx := strings.Index(name, ","); idx != -1 {
-			name = name[:idx]
-		}
-		if name == "-" {
-			continue
-		}
-		if name == "" {
-			name = field.Name
-		}
-
-		// Generate example value for the field
-		var value interface{}
-		switch field.Type.Kind() {
-		case reflect.Struct:
-			if field.Type.String() == "time.Time" {
-				value = "2025-02-22T08:36:06.224266+01:00"
-			} else {
-				value = generateExample(field.Type)
-			}
-		case reflect.Slice, reflect.Array:
-			if elemExample := generateExample(field.Type.Elem()); elemExample != nil {
-				value = []interface{}{elemExample}
-			}
-		default:
-			value = getExampleValue(field.Type)
-		}
-
-		if value != nil {
-			example[name] = value
-		}
-	}
-
-	return example
-}
-
-// WriteJSON writes a JSON representation of the value to the writer
+// WriteJSON writes a JSON representation of the value to the writer.
+// Characters such as <, > and & are written as is rather than escaped for
+// embedding in HTML, so descriptions read the same in the served document.
 func WriteJSON(w io.Writer, value interface{}) error {
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	encoder.SetEscapeHTML(false)
 	return encoder.Encode(value)
 }
+
+// marshalJSON is json.Marshal without HTML escaping. The custom MarshalJSON
+// methods use it since the escaping of their output can't be undone by the
+// encoder of WriteJSON.
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
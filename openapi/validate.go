@@ -0,0 +1,121 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/joakimcarlsson/go-router/metadata"
+)
+
+// WithStrictValidation enables validation of the routes passed to Generate.
+// When enabled, Generate panics with the combined validation errors instead of
+// silently producing a broken specification. Warnings do not cause a panic.
+func (g *Generator) WithStrictValidation(strict bool) {
+	g.strictValidation = strict
+}
+
+// ValidationWarning is reported by Validate for documentation that is valid
+// but most likely a mistake. Strict validation does not panic on warnings.
+type ValidationWarning struct {
+	Operation string
+	Message   string
+}
+
+// Error implements the error interface
+func (w *ValidationWarning) Error() string {
+	return "warning: " + w.Operation + ": " + w.Message
+}
+
+// Validate checks the given routes for common documentation mistakes.
+// It reports path parameters that are not documented, security requirements
+// referencing undefined schemes, references to undefined parameter and
+// request body components, and duplicate operationIds. A POST, PUT or PATCH
+// whose request body is not required is reported as a ValidationWarning.
+func (g *Generator) Validate(routes []RouteInfo) []error {
+	var errs []error
+	operationIDs := make(map[string]string)
+
+	for _, route := range documentedRoutes(routes) {
+		operation := route.Method() + " " + route.Path()
+
+		documented := make(map[string]bool)
+		for _, param := range route.Parameters() {
+			if param.Ref != "" {
+				component, ok := g.parameters[param.Ref]
+				if !ok {
+					errs = append(errs, fmt.Errorf("%s: parameter component %q is not defined", operation, param.Ref))
+					continue
+				}
+				param = component
+			}
+			if param.In == "path" {
+				documented[param.Name] = true
+			}
+		}
+		for _, name := range metadata.PathParamNames(route.Path()) {
+			if !documented[name] {
+				errs = append(errs, fmt.Errorf("%s: path parameter %q is not documented", operation, name))
+			}
+		}
+
+		if rb := route.RequestBody(); rb != nil {
+			body, ok := *rb, true
+			if rb.Ref != "" {
+				if body, ok = g.requestBodies[rb.Ref]; !ok {
+					errs = append(errs, fmt.Errorf("%s: request body component %q is not defined", operation, rb.Ref))
+				}
+			}
+			if ok && !body.Required && isWriteMethod(route.Method()) {
+				errs = append(errs, &ValidationWarning{
+					Operation: operation,
+					Message:   "request body is not required",
+				})
+			}
+		}
+
+		for _, requirement := range route.Security() {
+			for scheme := range requirement {
+				if _, ok := g.securitySchemes[scheme]; !ok {
+					errs = append(errs, fmt.Errorf("%s: security scheme %q is not defined", operation, scheme))
+				}
+			}
+		}
+
+		if id := route.OperationID(); id != "" {
+			if previous, ok := operationIDs[id]; ok {
+				errs = append(errs, fmt.Errorf("%s: operationId %q is already used by %s", operation, id, previous))
+			} else {
+				operationIDs[id] = operation
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateOrPanic runs Validate when strict validation is enabled
+// and panics with the combined errors if any were found.
+func (g *Generator) validateOrPanic(routes []RouteInfo) {
+	if !g.strictValidation {
+		return
+	}
+	var invalid []error
+	for _, err := range g.Validate(routes) {
+		var warning *ValidationWarning
+		if !errors.As(err, &warning) {
+			invalid = append(invalid, err)
+		}
+	}
+	if len(invalid) > 0 {
+		panic(fmt.Sprintf("openapi: invalid specification: %v", errors.Join(invalid...)))
+	}
+}
+
+// isWriteMethod reports whether method usually carries a request body
+func isWriteMethod(method string) bool {
+	switch method {
+	case "POST", "PUT", "PATCH":
+		return true
+	}
+	return false
+}
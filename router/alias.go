@@ -0,0 +1,121 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/joakimcarlsson/go-router/metadata"
+)
+
+// Alias makes the routes registered on the router at existingPath reachable
+// at aliasPath too, for every method, such as after renaming a path while
+// keeping the old one working:
+//
+//	r.GET("/users/{id}", getUser)
+//	r.Alias("/members/{id}", "/users/{id}")
+//
+// Both paths run the same handler with the same middleware. The alias is
+// excluded from the documentation, which only lists the canonical path.
+// Both paths are relative to the router's prefix and must have the same
+// path parameters. It panics if no route is registered at existingPath.
+func (r *Router) Alias(aliasPath, existingPath string) {
+	r.alias(aliasPath, existingPath, false)
+}
+
+// RedirectAlias works like Alias, but answers requests to aliasPath with a
+// permanent redirect to existingPath instead of running the handler, so
+// clients learn the canonical path. GET and HEAD requests are redirected with
+// 301 Moved Permanently and other methods with 308 Permanent Redirect, which
+// keeps the method and body. The query string is kept.
+func (r *Router) RedirectAlias(aliasPath, existingPath string) {
+	r.alias(aliasPath, existingPath, true)
+}
+
+// alias registers the routes at existingPath again under aliasPath.
+func (r *Router) alias(aliasPath, existingPath string, redirect bool) {
+	existing, _ := parsePathConstraints(normalizePath(path.Join(r.prefix, existingPath)))
+	fullpath, constraints := parsePathConstraints(normalizePath(path.Join(r.prefix, aliasPath)))
+
+	params, aliasParams := metadata.PathParamNames(existing), metadata.PathParamNames(fullpath)
+	slices.Sort(params)
+	slices.Sort(aliasParams)
+	if !slices.Equal(params, aliasParams) {
+		panic(fmt.Sprintf("router: alias %q must have the same path parameters as %q", aliasPath, existingPath))
+	}
+
+	r.mu.RLock()
+	var routes []route
+	for _, rt := range r.routes {
+		if rt.path == existing {
+			routes = append(routes, rt)
+		}
+	}
+	r.mu.RUnlock()
+	if len(routes) == 0 {
+		panic(fmt.Sprintf("router: cannot alias %q, no route is registered at %q", aliasPath, existingPath))
+	}
+
+	for _, rt := range routes {
+		metadata := cloneMetadata(rt.metadata)
+		metadata.Path = fullpath
+		metadata.ExcludeFromDocs = true
+
+		// The handler of the existing route already runs the middleware
+		handler := rt.handler
+		if redirect {
+			handler = r.chainRoute(constraints, redirectToPattern(existing))
+		} else if len(constraints) > 0 {
+			handler = r.enforcePathConstraints(constraints, handler)
+		}
+
+		r.addRoute(route{
+			method:   rt.method,
+			path:     fullpath,
+			handler:  handler,
+			metadata: &metadata,
+		}, r)
+	}
+}
+
+// redirectToPattern returns a handler that permanently redirects to the
+// path pattern, filled in with the request's path parameters.
+func redirectToPattern(pattern string) HandlerFunc {
+	return func(c *Context) {
+		var b strings.Builder
+		p := pattern
+		for {
+			start := strings.Index(p, "{")
+			end := strings.Index(p, "}")
+			if start == -1 || end < start {
+				break
+			}
+			b.WriteString(p[:start])
+			name := p[start+1 : end]
+			if wildcard, ok := strings.CutSuffix(name, "..."); ok {
+				segments := strings.Split(c.Param(wildcard), "/")
+				for i, segment := range segments {
+					segments[i] = url.PathEscape(segment)
+				}
+				b.WriteString(strings.Join(segments, "/"))
+			} else if name != "$" {
+				b.WriteString(url.PathEscape(c.Param(name)))
+			}
+			p = p[end+1:]
+		}
+		b.WriteString(p)
+
+		target := b.String()
+		if c.Request.URL.RawQuery != "" {
+			target += "?" + c.Request.URL.RawQuery
+		}
+		code := http.StatusPermanentRedirect
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+		c.Redirect(code, target)
+	}
+}
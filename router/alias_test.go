@@ -0,0 +1,70 @@
+package router_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+func TestAlias(t *testing.T) {
+	r := router.New()
+	r.GET("/users/{id}", func(c *router.Context) {
+		c.JSON(200, map[string]string{"id": c.Param("id")})
+	})
+	r.Alias("/members/{id}", "/users/{id}")
+
+	for _, path := range []string{"/users/7", "/members/7"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != 200 || w.Body.String() != "{\"id\":\"7\"}\n" {
+			t.Errorf("GET %s: expected the handler's response, got %d %q", path, w.Code, w.Body.String())
+		}
+	}
+
+	routes := r.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("expected the route and its alias, got %d routes", len(routes))
+	}
+	for _, route := range routes {
+		if excluded := route.Metadata.ExcludeFromDocs; excluded != (route.Path == "/members/{id}") {
+			t.Errorf("%s: expected only the alias to be excluded from docs, got ExcludeFromDocs=%v", route.Path, excluded)
+		}
+	}
+}
+
+func TestRedirectAlias(t *testing.T) {
+	r := router.New()
+	r.Group("/api", func(api *router.Router) {
+		api.GET("/users/{id}", func(c *router.Context) {})
+		api.PUT("/users/{id}", func(c *router.Context) {})
+		api.RedirectAlias("/members/{id}", "/users/{id}")
+	})
+
+	tests := []struct {
+		method string
+		code   int
+	}{
+		{"GET", 301},
+		{"PUT", 308},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, "/api/members/a%20b?expand=true", nil))
+		if w.Code != tt.code {
+			t.Errorf("%s: expected %d, got %d", tt.method, tt.code, w.Code)
+		}
+		if got := w.Header().Get("Location"); got != "/api/users/a%20b?expand=true" {
+			t.Errorf("%s: unexpected Location %q", tt.method, got)
+		}
+	}
+}
+
+func TestAliasUnknownPathPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Alias to panic for an unregistered path")
+		}
+	}()
+	router.New().Alias("/members", "/users")
+}
@@ -0,0 +1,121 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// BindError describes a request body that could not be bound, with enough
+// detail to tell the client what to fix:
+//
+//	var bindErr *router.BindError
+//	if errors.As(err, &bindErr) && bindErr.Field != "" {
+//	    c.Problem(http.StatusBadRequest, router.BadRequestProblem(bindErr.Error()))
+//	}
+//
+// The error it wraps, such as a *json.UnmarshalTypeError, is available
+// through errors.As.
+type BindError struct {
+	// Field is the path of the offending field, such as "address.zip",
+	// or empty when the body isn't well-formed
+	Field string
+	// Expected is the JSON type the field requires, such as "integer"
+	Expected string
+	// Got describes the value that was sent, such as "string"
+	Got string
+	// Offset is the byte offset in the body where the error was detected,
+	// or zero when it is not known
+	Offset int64
+	// Err is the underlying decoding error
+	Err error
+}
+
+// Error implements the error interface.
+func (e *BindError) Error() string {
+	switch {
+	case e.Field != "" && e.Expected != "":
+		return fmt.Sprintf("invalid value for field %q: expected %s, got %s", e.Field, e.Expected, e.Got)
+	case e.Field != "":
+		return fmt.Sprintf("unknown field %q", e.Field)
+	default:
+		return fmt.Sprintf("malformed JSON at offset %d: %v", e.Offset, e.Err)
+	}
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// bindError converts the errors of a JSON decoder into a *BindError. Other
+// errors are returned as is.
+func bindError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &typeErr):
+		return &BindError{
+			Field:    typeErr.Field,
+			Expected: jsonTypeName(typeErr.Type),
+			Got:      typeErr.Value,
+			Offset:   typeErr.Offset,
+			Err:      err,
+		}
+	case errors.As(err, &syntaxErr):
+		return &BindError{Offset: syntaxErr.Offset, Err: err}
+	}
+
+	// The decoder reports unknown fields with a plain error
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return &BindError{Field: strings.Trim(name, `"`), Err: err}
+	}
+	return err
+}
+
+// bindErrorAt is bindError for a JSON document that starts base bytes into
+// the request body, so the offset is reported relative to the body. A
+// negative base means the document isn't part of the body and the offset is
+// dropped.
+func bindErrorAt(err error, base int64) error {
+	err = bindError(err)
+	var bindErr *BindError
+	if !errors.As(err, &bindErr) || bindErr.Offset == 0 {
+		return err
+	}
+	if base < 0 {
+		bindErr.Offset = 0
+	} else {
+		bindErr.Offset += base
+	}
+	return err
+}
+
+// jsonTypeName returns the JSON type of values decoded into a Go type.
+func jsonTypeName(t reflect.Type) string {
+	if t == nil {
+		return "value"
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return t.String()
+	}
+}
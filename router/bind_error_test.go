@@ -0,0 +1,93 @@
+package router_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+type signup struct {
+	Name    string `json:"name"`
+	Address struct {
+		Zip int `json:"zip"`
+	} `json:"address"`
+}
+
+// bindSignup binds body to a signup through a route of r and returns the error.
+func bindSignup(t *testing.T, r *router.Router, body string) error {
+	t.Helper()
+	var err error
+	r.POST("/signup", func(c *router.Context) {
+		var s signup
+		err = c.BindJSON(&s)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/signup", strings.NewReader(body)))
+	return err
+}
+
+func TestBindJSONTypeMismatch(t *testing.T) {
+	err := bindSignup(t, router.New(), `{"name":"Ada","address":{"zip":"12345"}}`)
+
+	var bindErr *router.BindError
+	if !errors.As(err, &bindErr) {
+		t.Fatalf("expected a *BindError, got %T: %v", err, err)
+	}
+	if bindErr.Field != "address.zip" || bindErr.Expected != "integer" || bindErr.Got != "string" {
+		t.Fatalf("unexpected bind error %+v", bindErr)
+	}
+	if bindErr.Offset == 0 {
+		t.Fatal("expected the offset of the value")
+	}
+	if want := `invalid value for field "address.zip": expected integer, got string`; bindErr.Error() != want {
+		t.Fatalf("expected message %q, got %q", want, bindErr.Error())
+	}
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatal("expected the decoder's error to be wrapped")
+	}
+}
+
+func TestBindJSONSyntaxAndUnknownFieldErrors(t *testing.T) {
+	var bindErr *router.BindError
+	err := bindSignup(t, router.New(), `{"name":}`)
+	if !errors.As(err, &bindErr) || bindErr.Field != "" || bindErr.Offset != 9 {
+		t.Fatalf("expected a syntax error at offset 9, got %#v", err)
+	}
+
+	err = bindSignup(t, router.New().WithDisallowUnknownFields(true), `{"name":"Ada","nickname":"A"}`)
+	if !errors.As(err, &bindErr) || bindErr.Field != "nickname" {
+		t.Fatalf("expected an unknown field error naming nickname, got %#v", err)
+	}
+}
+
+func TestBindErrorOffsetsRelativeToBody(t *testing.T) {
+	var ndjsonErr, patchErr error
+	r := router.New()
+	r.POST("/signups", func(c *router.Context) {
+		ndjsonErr = c.BindNDJSON(func(decode func(interface{}) error) error {
+			var s signup
+			return decode(&s)
+		})
+	})
+	r.PATCH("/signup", func(c *router.Context) {
+		s := signup{Name: "Ada"}
+		patchErr = c.ApplyMergePatch(&s)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/signups",
+		strings.NewReader("{\"name\":\"Ada\"}\n {\"name\":}\n")))
+	var bindErr *router.BindError
+	if !errors.As(ndjsonErr, &bindErr) || bindErr.Offset != 25 {
+		t.Fatalf("expected an NDJSON syntax error at body offset 25, got %#v", ndjsonErr)
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PATCH", "/signup",
+		strings.NewReader(`{"address":{"zip":"12345"}}`)))
+	if !errors.As(patchErr, &bindErr) || bindErr.Field != "address.zip" || bindErr.Offset != 0 {
+		t.Fatalf("expected a merge patch error naming address.zip without an offset, got %#v", patchErr)
+	}
+}
@@ -1,10 +1,12 @@
 package router
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -16,6 +18,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/joakimcarlsson/go-router/metadata"
 )
 
 // Context represents the context of an HTTP request, including the request and response writer.
@@ -24,26 +28,60 @@ import (
 type Context struct {
 	// Writer is the http.ResponseWriter for the current request
 	Writer http.ResponseWriter
+	// writer wraps the server's response writer to track the written status
+	writer responseWriter
 	// Request is the *http.Request instance for the current request
 	Request *http.Request
-	ctx     context.Context
 	// StartTime records when the context was created for tracking request duration
 	StartTime time.Time
 	// StatusCode holds the HTTP status code that will be or has been sent
 	StatusCode int
-	// store provides a per-request key/value store
+	// inline holds the first entries of the per-request key/value store,
+	// avoiding a map for the common case of a handful of keys
+	inline    [inlineStoreSize]storeEntry
+	inlineLen int
+	// store holds the entries of the key/value store that do not fit inline
 	store map[string]interface{}
-	mu    sync.RWMutex
+	// concurrent makes the store lock mu, set by GoSafe
+	concurrent bool
+	mu         sync.RWMutex
 	// maxMultipartMemory specifies the maximum memory used for parsing multipart forms
 	maxMultipartMemory int64
+	// profileMiddleware enables recording of named middleware timings
+	profileMiddleware bool
+	// disallowUnknownFields makes BindJSON reject unknown fields
+	disallowUnknownFields bool
+	// middlewareTimings holds the time spent in each named middleware
+	middlewareTimings map[string]time.Duration
+	// rawBody caches the request body once it has been read by RawBody
+	rawBody []byte
+	// routeMetadata is the documented metadata of the matched route
+	routeMetadata *metadata.RouteMetadata
+	// routePattern is the registered path pattern of the matched route
+	routePattern string
+	// renderers are the response renderers registered on the router
+	renderers []contentRenderer
+	// problemLocalizer adapts problem responses, set from the router
+	problemLocalizer ProblemLocalizer
 }
 
+// inlineStoreSize is the number of key/value store entries kept inline
+const inlineStoreSize = 4
+
+// storeEntry is an entry of the inline key/value store
+type storeEntry struct {
+	key   string
+	value interface{}
+}
+
+// ErrEmptyBody is returned by BindJSON when the request has no body,
+// allowing handlers to distinguish a missing body from malformed JSON.
+var ErrEmptyBody = errors.New("request body is empty")
+
 // Context pool to minimize allocations
 var contextPool = sync.Pool{
 	New: func() interface{} {
-		return &Context{
-			store: make(map[string]interface{}),
-		}
+		return &Context{}
 	},
 }
 
@@ -79,9 +117,9 @@ var (
 // This is called by the router for each incoming request.
 func acquireContext(w http.ResponseWriter, r *http.Request) *Context {
 	ctx := contextPool.Get().(*Context)
-	ctx.Writer = w
+	ctx.writer = responseWriter{ResponseWriter: w, status: http.StatusOK}
+	ctx.Writer = &ctx.writer
 	ctx.Request = r
-	ctx.ctx = r.Context()
 	ctx.StartTime = time.Now()
 	ctx.StatusCode = http.StatusOK
 	ctx.maxMultipartMemory = 32 << 20 // 32 MB
@@ -90,13 +128,100 @@ func acquireContext(w http.ResponseWriter, r *http.Request) *Context {
 
 // releaseContext returns a Context to the pool and clears its data.
 // This is called after a request has been processed to allow the context to be reused.
+// Temporary files created while parsing a multipart form are removed here,
+// since the server only cleans up forms parsed on its own request value.
 func releaseContext(ctx *Context) {
+	if ctx.Request != nil && ctx.Request.MultipartForm != nil {
+		ctx.Request.MultipartForm.RemoveAll()
+	}
 	ctx.Writer = nil
+	ctx.writer = responseWriter{}
 	ctx.Request = nil
+	ctx.profileMiddleware = false
+	ctx.middlewareTimings = nil
+	ctx.disallowUnknownFields = false
+	ctx.rawBody = nil
+	ctx.routeMetadata = nil
+	ctx.routePattern = ""
+	ctx.renderers = nil
+	ctx.problemLocalizer = nil
+	ctx.inline = [inlineStoreSize]storeEntry{}
+	ctx.inlineLen = 0
+	ctx.concurrent = false
 	clearInterfaceMap(ctx.store)
 	contextPool.Put(ctx)
 }
 
+// Copy returns a copy of the Context that is not pooled, for goroutines that
+// outlive the handler. The Context itself is reused for another request once
+// the handler returns, so it must not be used from such goroutines.
+//
+// The copy keeps the request, its path parameters, context values and a
+// snapshot of the store, but its context is not canceled when the handler
+// returns. A body already read with RawBody can be read again; otherwise the
+// body is empty, and uploaded files are not available. The copy has no Writer and must not be used to write the
+// response, which belongs to the original Context.
+func (c *Context) Copy() *Context {
+	cp := &Context{
+		Request:               c.Request.Clone(context.WithoutCancel(c.Context())),
+		StartTime:             c.StartTime,
+		StatusCode:            c.StatusCode,
+		maxMultipartMemory:    c.maxMultipartMemory,
+		disallowUnknownFields: c.disallowUnknownFields,
+		rawBody:               c.rawBody,
+		routeMetadata:         c.routeMetadata,
+		routePattern:          c.routePattern,
+		renderers:             c.renderers,
+		problemLocalizer:      c.problemLocalizer,
+	}
+	cp.Request.Body = http.NoBody
+	if c.rawBody != nil {
+		cp.Request.Body = io.NopCloser(bytes.NewReader(c.rawBody))
+	}
+	// Uploaded files are removed when the original Context is released
+	cp.Request.MultipartForm = nil
+
+	c.mu.RLock()
+	cp.inline = c.inline
+	cp.inlineLen = c.inlineLen
+	if len(c.store) > 0 {
+		cp.store = make(map[string]interface{}, len(c.store))
+		for key, value := range c.store {
+			cp.store[key] = value
+		}
+	}
+	c.mu.RUnlock()
+	return cp
+}
+
+// RouteMetadata returns the documented metadata of the route handling the
+// request, such as its security requirements. It is nil outside of a route.
+func (c *Context) RouteMetadata() *metadata.RouteMetadata {
+	return c.routeMetadata
+}
+
+// RouteAnnotation returns the value of an annotation added to the route
+// handling the request with docs.WithAnnotation, and whether it is set.
+//
+//	if tier, ok := c.RouteAnnotation("rate-limit-tier"); ok {
+//		limiter = limiters[tier]
+//	}
+func (c *Context) RouteAnnotation(key string) (string, bool) {
+	if c.routeMetadata == nil {
+		return "", false
+	}
+	value, ok := c.routeMetadata.Annotations[key]
+	return value, ok
+}
+
+// RoutePattern returns the registered path pattern of the route handling the
+// request, such as "/users/{id}" for a request to "/users/7". Unlike the
+// request path it has low cardinality, which makes it suitable as a metrics
+// or log label. It is empty outside of a route.
+func (c *Context) RoutePattern() string {
+	return c.routePattern
+}
+
 // Query returns the query parameters of the request.
 // Returns the same structure as http.Request.URL.Query().
 func (c *Context) Query() url.Values {
@@ -183,7 +308,21 @@ func (c *Context) Param(key string) string {
 
 // JSON writes the given object as a JSON response with the given status code.
 // It sets the Content-Type header to "application/json; charset=utf-8".
+// The object is encoded before anything is written, so if encoding fails the
+// client receives a plain 500 Internal Server Error instead of a partial body.
 func (c *Context) JSON(code int, obj interface{}) {
+	c.writeJSON(code, obj, false)
+}
+
+// JSONBuffered works like JSON but also sets the Content-Length header,
+// for clients and proxies that don't cope well with responses of unknown length.
+func (c *Context) JSONBuffered(code int, obj interface{}) {
+	c.writeJSON(code, obj, true)
+}
+
+// writeJSON encodes obj into a pooled buffer and writes it in a single call,
+// optionally announcing the body size with Content-Length.
+func (c *Context) writeJSON(code int, obj interface{}, setLength bool) {
 	container := jsonEncoderPool.Get().(*EncoderContainer)
 	container.Buffer.Reset()
 	encoder := container.Encoder.(*json.Encoder)
@@ -195,6 +334,9 @@ func (c *Context) JSON(code int, obj interface{}) {
 	}
 
 	c.SetHeader("Content-Type", "application/json; charset=utf-8")
+	if setLength {
+		c.SetHeader("Content-Length", strconv.Itoa(container.Buffer.Len()))
+	}
 	c.Status(code)
 	c.Writer.Write(container.Buffer.Bytes())
 	jsonEncoderPool.Put(container)
@@ -231,6 +373,43 @@ func (c *Context) File(filepath string) {
 	http.ServeFile(c.Writer, c.Request, filepath)
 }
 
+// SetLastModified sets the Last-Modified response header to t.
+// A zero time leaves the header unset.
+func (c *Context) SetLastModified(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	c.SetHeader("Last-Modified", t.UTC().Format(http.TimeFormat))
+}
+
+// NotModifiedSince reports whether the client's copy, as dated by the
+// If-Modified-Since request header, is still current for content last
+// modified at modtime. Handlers can then respond with 304 Not Modified
+// instead of the body:
+//
+//	c.SetLastModified(modtime)
+//	if c.NotModifiedSince(modtime) {
+//	    c.Status(http.StatusNotModified)
+//	    return
+//	}
+//
+// Only GET and HEAD requests are considered, and the header is ignored when
+// If-None-Match is present, as it takes precedence.
+func (c *Context) NotModifiedSince(modtime time.Time) bool {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+	if modtime.IsZero() || c.Request.Header.Get("If-None-Match") != "" {
+		return false
+	}
+	since, err := http.ParseTime(c.Request.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// The header has a resolution of one second
+	return !modtime.Truncate(time.Second).After(since)
+}
+
 // Redirect performs an HTTP redirect to the specified location.
 func (c *Context) Redirect(code int, location string) {
 	http.Redirect(c.Writer, c.Request, location, code)
@@ -242,10 +421,67 @@ func (c *Context) Error(code int, message string) {
 }
 
 // Status sets the HTTP status code for the response.
-// This method writes the status code to the response writer.
+// This method writes the status code to the response writer. Once the
+// header has been written, later calls keep the first status code, as do
+// the response methods built on Status such as JSON.
 func (c *Context) Status(code int) {
-	c.StatusCode = code
 	c.Writer.WriteHeader(code)
+	if c.writer.ResponseWriter != nil {
+		c.StatusCode = c.writer.status
+		return
+	}
+	c.StatusCode = code
+}
+
+// NoContent sends a 204 No Content response, as DELETE handlers typically
+// do. The response is complete once it returns: a body written afterwards by
+// mistake is dropped, and the write returns http.ErrBodyNotAllowed, instead
+// of reaching the client.
+func (c *Context) NoContent() {
+	c.Writer.Header().Del("Content-Type")
+	c.Status(http.StatusNoContent)
+}
+
+// WrapWriter replaces Writer with the writer fn returns, which wraps the
+// current one, so the middleware and handler further down the chain write
+// through it. It is meant for middleware that transforms responses:
+//
+//	restore := c.WrapWriter(func(w http.ResponseWriter) http.ResponseWriter {
+//		return &gzipWriter{ResponseWriter: w, zw: zw}
+//	})
+//	next(c)
+//	restore()
+//
+// The returned restore function puts the previous writer back, so the
+// middleware further up the chain doesn't write through the wrapper once
+// next returns.
+// The wrapper must pass the status code on to the writer it wraps, which
+// tracks the status set with Status and drops a second WriteHeader.
+// Implementing Unwrap() http.ResponseWriter keeps http.ResponseController
+// working through the wrapper.
+func (c *Context) WrapWriter(fn func(http.ResponseWriter) http.ResponseWriter) (restore func()) {
+	previous := c.Writer
+	c.Writer = fn(previous)
+	return func() { c.Writer = previous }
+}
+
+// EarlyHints sends a 103 Early Hints response with the given Link header
+// values, letting browsers start fetching critical assets while the final
+// response is being prepared:
+//
+//	c.EarlyHints(`</app.css>; rel=preload; as=style`, `</app.js>; rel=preload; as=script`)
+//
+// The links are also kept on the final response. It does nothing once the
+// response header has been written or when the client uses HTTP/1.0, which
+// does not support informational responses.
+func (c *Context) EarlyHints(links ...string) {
+	if len(links) == 0 || c.writer.wroteHeader || !c.Request.ProtoAtLeast(1, 1) {
+		return
+	}
+	for _, link := range links {
+		c.AddHeader("Link", link)
+	}
+	c.Writer.WriteHeader(http.StatusEarlyHints)
 }
 
 // GetHeader returns the value of the request header with the given key.
@@ -258,10 +494,65 @@ func (c *Context) SetHeader(key, value string) {
 	c.Writer.Header().Set(key, value)
 }
 
+// SetHeaders sets the values of several response headers at once.
+func (c *Context) SetHeaders(headers map[string]string) {
+	h := c.Writer.Header()
+	for key, value := range headers {
+		h.Set(key, value)
+	}
+}
+
+// AddHeader adds a value to the response header with the given key.
+// Unlike SetHeader it keeps existing values, which is needed for
+// multi-value headers such as Set-Cookie or Link.
+func (c *Context) AddHeader(key, value string) {
+	c.Writer.Header().Add(key, value)
+}
+
+// RawBody reads the whole request body and returns it. The body is read only
+// once and cached on the Context, and Request.Body is replaced with a fresh
+// reader over the same bytes, so BindJSON and friends still work afterwards.
+// This is useful for verifying webhook signatures before binding.
+func (c *Context) RawBody() ([]byte, error) {
+	if c.rawBody != nil {
+		c.Request.Body = io.NopCloser(bytes.NewReader(c.rawBody))
+		return c.rawBody, nil
+	}
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return []byte{}, nil
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	c.rawBody = body
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
 // BindJSON binds the request body to the given target object.
-// Returns an error if the binding fails.
+// Returns ErrEmptyBody if the request has no body and another error if the
+// binding fails, a *BindError naming the offending field when a value has
+// the wrong type. When the router is configured with WithDisallowUnknownFields,
+// unknown fields in the body are a *BindError naming the field too.
 func (c *Context) BindJSON(target interface{}) error {
-	return json.NewDecoder(c.Request.Body).Decode(target)
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return ErrEmptyBody
+	}
+
+	decoder := json.NewDecoder(c.Request.Body)
+	if c.disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(target); err != nil {
+		if err == io.EOF {
+			return ErrEmptyBody
+		}
+		return bindError(err)
+	}
+	return nil
 }
 
 // BindXML binds XML request body to a struct.
@@ -270,6 +561,55 @@ func (c *Context) BindXML(obj interface{}) error {
 	return xml.NewDecoder(c.Request.Body).Decode(obj)
 }
 
+// BindNDJSON reads a newline-delimited JSON (NDJSON) request body record by record.
+// The callback is invoked once per record with a decode function that unmarshals
+// the current record into a target, so memory use stays flat for large uploads.
+// Blank lines are skipped and a leading record separator (RFC 7464, application/json-seq)
+// is ignored. Returns the first error from reading the body or from the callback.
+//
+// Example:
+//
+//	err := c.BindNDJSON(func(decode func(interface{}) error) error {
+//	    var todo Todo
+//	    if err := decode(&todo); err != nil {
+//	        return err
+//	    }
+//	    return store.Create(todo)
+//	})
+func (c *Context) BindNDJSON(each func(decode func(interface{}) error) error) error {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil
+	}
+
+	reader := bufio.NewReader(c.Request.Body)
+	var offset int64
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+
+		record := bytes.TrimSpace(bytes.TrimLeft(line, "\x1e"))
+		if len(record) > 0 {
+			start := offset + int64(bytes.Index(line, record))
+			decode := func(target interface{}) error {
+				if err := json.Unmarshal(record, target); err != nil {
+					return bindErrorAt(err, start)
+				}
+				return nil
+			}
+			if err := each(decode); err != nil {
+				return err
+			}
+		}
+		offset += int64(len(line))
+
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
+
 // BindForm binds form data (including multipart form data) to a struct.
 // It uses struct tags to map form fields to struct fields:
 //   - `form:"name"` tag for form field mapping
@@ -379,28 +719,90 @@ func setValue(field reflect.Value, values []string) {
 	}
 }
 
+// GoSafe marks the Context as used by several goroutines, making Set and Get
+// safe to call concurrently. The store is not locked by default since most
+// handlers only use it from the goroutine serving the request, so GoSafe
+// must be called before spawning goroutines that use the store:
+//
+//	c.GoSafe()
+//	var wg sync.WaitGroup
+//	for _, id := range ids {
+//	    wg.Add(1)
+//	    go func(id string) {
+//	        defer wg.Done()
+//	        c.Set(id, lookup(id))
+//	    }(id)
+//	}
+//	wg.Wait()
+//
+// The goroutines must still finish before the handler returns, as the
+// Context is reused for another request afterwards; see Copy.
+func (c *Context) GoSafe() {
+	c.concurrent = true
+}
+
 // Set stores a key-value pair in the context.
 // This can be used to pass data between middleware and handlers.
+// The first few keys are kept inline; a map is only used beyond those.
+// Set is not safe for concurrent use unless GoSafe was called.
 func (c *Context) Set(key string, value interface{}) {
+	if !c.concurrent {
+		c.set(key, value)
+		return
+	}
 	c.mu.Lock()
-	c.store[key] = value
+	c.set(key, value)
 	c.mu.Unlock()
 }
 
+// set stores a key-value pair; the caller must hold the lock
+func (c *Context) set(key string, value interface{}) {
+	for i := 0; i < c.inlineLen; i++ {
+		if c.inline[i].key == key {
+			c.inline[i].value = value
+			return
+		}
+	}
+	if c.inlineLen < inlineStoreSize {
+		c.inline[c.inlineLen] = storeEntry{key: key, value: value}
+		c.inlineLen++
+		return
+	}
+	if c.store == nil {
+		c.store = make(map[string]interface{})
+	}
+	c.store[key] = value
+}
+
 // Get retrieves a value from the context by key.
 // Returns the value and a boolean indicating whether the key was found.
+// Get is not safe for concurrent use with Set unless GoSafe was called.
 func (c *Context) Get(key string) (interface{}, bool) {
+	if !c.concurrent {
+		return c.get(key)
+	}
 	c.mu.RLock()
-	value, exists := c.store[key]
+	value, exists := c.get(key)
 	c.mu.RUnlock()
 	return value, exists
 }
 
+// get retrieves a value by key; the caller must hold the lock
+func (c *Context) get(key string) (interface{}, bool) {
+	for i := 0; i < c.inlineLen; i++ {
+		if c.inline[i].key == key {
+			return c.inline[i].value, true
+		}
+	}
+	value, exists := c.store[key]
+	return value, exists
+}
+
 // GetString retrieves a string value from the context.
 // Returns the value and a boolean indicating whether the key was found
 // and the value was of type string.
 func (c *Context) GetString(key interface{}) (string, bool) {
-	if val := c.ctx.Value(key); val != nil {
+	if val := c.Context().Value(key); val != nil {
 		if str, ok := val.(string); ok {
 			return str, true
 		}
@@ -412,7 +814,7 @@ func (c *Context) GetString(key interface{}) (string, bool) {
 // Returns the value and a boolean indicating whether the key was found
 // and the value was of type int.
 func (c *Context) GetInt(key interface{}) (int, bool) {
-	if val := c.ctx.Value(key); val != nil {
+	if val := c.Context().Value(key); val != nil {
 		if i, ok := val.(int); ok {
 			return i, true
 		}
@@ -420,9 +822,26 @@ func (c *Context) GetInt(key interface{}) (int, bool) {
 	return 0, false
 }
 
-// Context returns the underlying context.Context.
+// Context returns the context of the request. It reflects values and
+// deadlines added with SetValue and SetContext, as well as a request
+// replaced by middleware through c.Request.
 func (c *Context) Context() context.Context {
-	return c.ctx
+	return c.Request.Context()
+}
+
+// SetContext replaces the context of the request, for example with one
+// carrying a timeout or a tracing span. Handlers further down the chain see
+// the new context through Context, Value and the typed getters.
+func (c *Context) SetContext(ctx context.Context) {
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// SetValue adds a value to the context of the request under key, making it
+// available through Value and the typed getters such as GetString and GetInt.
+// Unlike Set, the value is also visible to code that only receives the
+// request's context.Context.
+func (c *Context) SetValue(key, value interface{}) {
+	c.SetContext(context.WithValue(c.Context(), key, value))
 }
 
 // clearInterfaceMap clears an interface map by removing all entries.
@@ -437,43 +856,102 @@ func clearInterfaceMap(m map[string]interface{}) {
 // based on the Accept header and the offered content types.
 // If no matching content type is found, it returns the first offered type or "application/json" by default.
 func (c *Context) Negotiate(offered ...string) string {
+	if len(offered) == 0 {
+		return "application/json"
+	}
+	if contentType, ok := c.negotiate(offered); ok {
+		return contentType
+	}
+	return offered[0]
+}
+
+// negotiate returns the first offered content type accepted by the client,
+// reporting false when none is. A missing Accept header accepts anything.
+func (c *Context) negotiate(offered []string) (string, bool) {
 	accept := c.GetHeader("Accept")
 	if accept == "" {
-		if len(offered) > 0 {
-			return offered[0]
-		}
-		return "application/json"
+		return offered[0], true
 	}
 
-	accepts := strings.Split(accept, ",")
-	for _, accepted := range accepts {
-		mediaType := strings.Split(strings.TrimSpace(accepted), ";")[0]
+	for _, accepted := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.Split(accepted, ";")[0])
 		for _, offer := range offered {
-			if mediaType == offer || mediaType == "*/*" {
-				return offer
+			if mediaType == offer || mediaType == "*/*" ||
+				strings.HasSuffix(mediaType, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(mediaType, "*")) {
+				return offer, true
 			}
 		}
 	}
-
-	return offered[0]
+	return "", false
 }
 
 // Respond sends a response with content negotiation.
-// It chooses between JSON and XML based on the Accept header.
+// It chooses between JSON, XML and the renderers registered on the router
+// with WithRenderer based on the Accept header, falling back to JSON when
+// the client accepts none of them.
 func (c *Context) Respond(code int, obj interface{}) {
-	switch c.Negotiate("application/json", "application/xml") {
-	case "application/xml":
+	offered := c.offeredContentTypes()
+	contentType, ok := c.negotiate(offered)
+	if !ok {
+		contentType = offered[0]
+	}
+	c.render(code, contentType, obj)
+}
+
+// RespondOrFail is like Respond, but sends 406 Not Acceptable when the
+// client accepts none of the offered content types instead of falling back
+// to JSON.
+func (c *Context) RespondOrFail(code int, obj interface{}) {
+	offered := c.offeredContentTypes()
+	contentType, ok := c.negotiate(offered)
+	if !ok {
+		c.Problem(http.StatusNotAcceptable, NewProblem(http.StatusNotAcceptable,
+			"acceptable content types are "+strings.Join(offered, ", ")))
+		return
+	}
+	c.render(code, contentType, obj)
+}
+
+// offeredContentTypes returns the content types Respond can produce,
+// JSON and XML first, followed by the registered renderers
+func (c *Context) offeredContentTypes() []string {
+	offered := []string{"application/json", "application/xml"}
+	for _, r := range c.renderers {
+		if r.contentType != "application/json" && r.contentType != "application/xml" {
+			offered = append(offered, r.contentType)
+		}
+	}
+	return offered
+}
+
+// render writes obj in the given content type, preferring a registered
+// renderer over the built-in JSON and XML encoding
+func (c *Context) render(code int, contentType string, obj interface{}) {
+	for _, r := range c.renderers {
+		if r.contentType != contentType {
+			continue
+		}
+		data, err := r.render(obj)
+		if err != nil {
+			http.Error(c.Writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		c.Data(code, contentType, data)
+		return
+	}
+
+	if contentType == "application/xml" {
 		c.XML(code, obj)
-	default:
-		c.JSON(code, obj)
+		return
 	}
+	c.JSON(code, obj)
 }
 
 // GetDuration returns a duration from context.
 // Returns the value and a boolean indicating whether the key was found
 // and the value was of type time.Duration.
 func (c *Context) GetDuration(key interface{}) (time.Duration, bool) {
-	if val := c.ctx.Value(key); val != nil {
+	if val := c.Context().Value(key); val != nil {
 		if d, ok := val.(time.Duration); ok {
 			return d, true
 		}
@@ -484,25 +962,25 @@ func (c *Context) GetDuration(key interface{}) (time.Duration, bool) {
 // Deadline returns the context deadline and ok flag.
 // Implements context.Context interface.
 func (c *Context) Deadline() (time.Time, bool) {
-	return c.ctx.Deadline()
+	return c.Context().Deadline()
 }
 
 // Done returns the context's Done channel.
 // Implements context.Context interface.
 func (c *Context) Done() <-chan struct{} {
-	return c.ctx.Done()
+	return c.Context().Done()
 }
 
 // Err returns the context's error.
 // Implements context.Context interface.
 func (c *Context) Err() error {
-	return c.ctx.Err()
+	return c.Context().Err()
 }
 
 // Value returns the context's value for key.
 // Implements context.Context interface.
 func (c *Context) Value(key interface{}) interface{} {
-	return c.ctx.Value(key)
+	return c.Context().Value(key)
 }
 
 // Elapsed returns the time elapsed since the context was created.
@@ -537,6 +1015,16 @@ func (c *Context) MultipartForm() (*multipart.Form, error) {
 	return c.Request.MultipartForm, nil
 }
 
+// MultipartReader returns a reader over the parts of a multipart/form-data
+// request body, so that each part can be streamed to its destination as it
+// arrives. Unlike MultipartForm and BindForm, which buffer the form in memory
+// up to the multipart memory limit and spill the rest to temporary files,
+// nothing is buffered, but parts can only be read once and in order.
+// It can't be combined with MultipartForm, BindForm or FormFile on the same request.
+func (c *Context) MultipartReader() (*multipart.Reader, error) {
+	return c.Request.MultipartReader()
+}
+
 // FormValue returns the first value for the named component of the form data.
 // It tries the URL query parameters first, then the POST or PUT form data.
 func (c *Context) FormValue(name string) string {
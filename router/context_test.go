@@ -0,0 +1,755 @@
+package router_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/go-router/docs"
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+// serve registers handler for GET /test on a new router and performs a request.
+func serve(t *testing.T, handler router.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	r := router.New()
+	r.GET("/test", handler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+	return w
+}
+
+func TestContextProblem(t *testing.T) {
+	w := serve(t, func(c *router.Context) {
+		c.Problem(404, router.NewProblem(404, "user 42 does not exist").
+			With("userId", 42))
+	})
+
+	if w.Code != 404 {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("expected application/problem+json, got %q", ct)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"type":   "about:blank",
+		"title":  "Not Found",
+		"status": float64(404),
+		"detail": "user 42 does not exist",
+		"userId": float64(42),
+	}
+	for k, v := range expected {
+		if body[k] != v {
+			t.Fatalf("expected %s=%v, got %v", k, v, body[k])
+		}
+	}
+}
+
+func TestContextSetHeaders(t *testing.T) {
+	w := serve(t, func(c *router.Context) {
+		c.SetHeaders(map[string]string{
+			"X-Request-ID": "abc",
+			"X-Version":    "1",
+		})
+		c.Status(204)
+	})
+
+	if w.Header().Get("X-Request-ID") != "abc" || w.Header().Get("X-Version") != "1" {
+		t.Fatalf("expected both headers to be set, got %v", w.Header())
+	}
+}
+
+func TestContextAddHeaderAccumulates(t *testing.T) {
+	w := serve(t, func(c *router.Context) {
+		c.AddHeader("Link", `</page/2>; rel="next"`)
+		c.AddHeader("Link", `</page/9>; rel="last"`)
+		c.Status(204)
+	})
+
+	links := w.Header().Values("Link")
+	if len(links) != 2 || links[0] != `</page/2>; rel="next"` || links[1] != `</page/9>; rel="last"` {
+		t.Fatalf("expected two Link values, got %v", links)
+	}
+}
+
+func TestBindJSONDisallowUnknownFields(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+	body := `{"name":"gopher","nmae":"typo"}`
+
+	for _, strict := range []bool{false, true} {
+		var bindErr error
+		r := router.New().WithDisallowUnknownFields(strict)
+		r.POST("/test", func(c *router.Context) {
+			var p payload
+			bindErr = c.BindJSON(&p)
+		})
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/test", strings.NewReader(body)))
+
+		if !strict && bindErr != nil {
+			t.Fatalf("expected lenient bind to succeed, got %v", bindErr)
+		}
+		if strict && (bindErr == nil || !strings.Contains(bindErr.Error(), `"nmae"`)) {
+			t.Fatalf("expected strict bind to fail naming the field, got %v", bindErr)
+		}
+	}
+}
+
+func TestBindJSONEmptyBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		check   func(error) bool
+		wantErr string
+	}{
+		{"empty", "", func(err error) bool { return errors.Is(err, router.ErrEmptyBody) }, "ErrEmptyBody"},
+		{"malformed", `{"name":`, func(err error) bool { return err != nil && !errors.Is(err, router.ErrEmptyBody) }, "a decode error"},
+		{"valid", `{"name":"gopher"}`, func(err error) bool { return err == nil }, "no error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bindErr error
+			r := router.New()
+			r.PATCH("/test", func(c *router.Context) {
+				var p struct {
+					Name string `json:"name"`
+				}
+				bindErr = c.BindJSON(&p)
+			})
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PATCH", "/test", strings.NewReader(tt.body)))
+
+			if !tt.check(bindErr) {
+				t.Fatalf("expected %s, got %v", tt.wantErr, bindErr)
+			}
+		})
+	}
+}
+
+func TestBindNDJSON(t *testing.T) {
+	type record struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	body := "{\"id\":1,\"name\":\"a\"}\n\n{\"id\":2,\"name\":\"b\"}\n{\"id\":3,\"name\":\"c\"}"
+
+	var records []record
+	var bindErr error
+	r := router.New()
+	r.POST("/test", func(c *router.Context) {
+		bindErr = c.BindNDJSON(func(decode func(interface{}) error) error {
+			var rec record
+			if err := decode(&rec); err != nil {
+				return err
+			}
+			records = append(records, rec)
+			return nil
+		})
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/test", strings.NewReader(body)))
+
+	if bindErr != nil {
+		t.Fatal(bindErr)
+	}
+	if len(records) != 3 || records[0].ID != 1 || records[2].Name != "c" {
+		t.Fatalf("unexpected records %+v", records)
+	}
+}
+
+func TestRawBodyThenBindJSON(t *testing.T) {
+	const body = `{"event":"push"}`
+	var (
+		first, second []byte
+		payload       struct {
+			Event string `json:"event"`
+		}
+		bindErr error
+	)
+
+	r := router.New()
+	r.POST("/webhook", func(c *router.Context) {
+		first, _ = c.RawBody()
+		second, _ = c.RawBody()
+		bindErr = c.BindJSON(&payload)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/webhook", strings.NewReader(body)))
+
+	if string(first) != body || string(second) != body {
+		t.Fatalf("expected RawBody to return the body on every call, got %q and %q", first, second)
+	}
+	if bindErr != nil || payload.Event != "push" {
+		t.Fatalf("expected BindJSON to work after RawBody, got %v (%+v)", bindErr, payload)
+	}
+}
+
+func TestJSONBufferedSetsContentLength(t *testing.T) {
+	body := map[string]string{"hello": "world"}
+
+	w := serve(t, func(c *router.Context) { c.JSONBuffered(200, body) })
+	if cl := w.Header().Get("Content-Length"); cl != strconv.Itoa(w.Body.Len()) {
+		t.Fatalf("expected Content-Length %d in buffered mode, got %q", w.Body.Len(), cl)
+	}
+
+	w = serve(t, func(c *router.Context) { c.JSON(200, body) })
+	if cl := w.Header().Get("Content-Length"); cl != "" {
+		t.Fatalf("expected no Content-Length in default mode, got %q", cl)
+	}
+}
+
+func TestContextRoutePattern(t *testing.T) {
+	var pattern string
+	r := router.New()
+	r.Group("/users", func(g *router.Router) {
+		g.GET("/{id:int}", func(c *router.Context) { pattern = c.RoutePattern() })
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/7", nil))
+
+	if pattern != "/users/{id}" {
+		t.Fatalf("expected /users/{id}, got %q", pattern)
+	}
+}
+
+func TestContextRouteAnnotation(t *testing.T) {
+	var tier, handlerTier string
+	var found bool
+	r := router.New()
+	r.Use(func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) {
+			tier, _ = c.RouteAnnotation("rate-limit-tier")
+			next(c)
+		}
+	})
+	r.GET("/reports", func(c *router.Context) {
+		handlerTier, found = c.RouteAnnotation("rate-limit-tier")
+		if _, ok := c.RouteAnnotation("audit-level"); ok {
+			t.Error("expected an unset annotation to be reported as missing")
+		}
+	}, docs.WithAnnotation("rate-limit-tier", "premium"))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/reports", nil))
+
+	if !found || handlerTier != "premium" {
+		t.Fatalf("expected the premium tier in the handler, got %q", handlerTier)
+	}
+	if tier != "premium" {
+		t.Fatalf("expected the premium tier in middleware, got %q", tier)
+	}
+}
+
+// upperWriter upper-cases the response body written through it.
+type upperWriter struct {
+	http.ResponseWriter
+}
+
+func (w *upperWriter) Write(b []byte) (int, error) {
+	return w.ResponseWriter.Write(bytes.ToUpper(b))
+}
+
+func TestContextNoContent(t *testing.T) {
+	var status int
+	r := router.New()
+	r.DELETE("/todos/{id}", func(c *router.Context) {
+		c.NoContent()
+		c.JSON(200, map[string]string{"status": "deleted"})
+		status = c.StatusCode
+		if _, err := c.Writer.Write([]byte("late")); !errors.Is(err, http.ErrBodyNotAllowed) {
+			t.Errorf("expected http.ErrBodyNotAllowed for a late write, got %v", err)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/todos/1", nil))
+
+	if w.Code != 204 || status != 204 {
+		t.Fatalf("expected status 204, got %d and %d", w.Code, status)
+	}
+	if w.Body.Len() != 0 {
+		t.Fatalf("expected no body, got %q", w.Body.String())
+	}
+}
+
+func TestContextWrapWriter(t *testing.T) {
+	var seen, before, after http.ResponseWriter
+	var status int
+	r := router.New()
+	r.Use(func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) {
+			before = c.Writer
+			next(c)
+			after = c.Writer
+		}
+	})
+	r.Use(func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) {
+			restore := c.WrapWriter(func(w http.ResponseWriter) http.ResponseWriter {
+				return &upperWriter{ResponseWriter: w}
+			})
+			next(c)
+			restore()
+		}
+	})
+	r.GET("/greeting", func(c *router.Context) {
+		seen = c.Writer
+		c.Status(201)
+		c.Status(500)
+		status = c.StatusCode
+		c.Writer.Write([]byte("hello"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/greeting", nil))
+
+	if _, ok := seen.(*upperWriter); !ok {
+		t.Fatalf("expected the handler to see the wrapped writer, got %T", seen)
+	}
+	if w.Body.String() != "HELLO" {
+		t.Fatalf("expected the body to go through the wrapper, got %q", w.Body.String())
+	}
+	if w.Code != 201 || status != 201 {
+		t.Fatalf("expected the first status to be kept and tracked, got %d and %d", w.Code, status)
+	}
+	if after != before {
+		t.Fatalf("expected restore to put back the previous writer, got %T", after)
+	}
+}
+
+func TestJSONEncodingErrorIsClean500(t *testing.T) {
+	for name, write := range map[string]func(*router.Context, interface{}){
+		"JSON":         func(c *router.Context, v interface{}) { c.JSON(200, v) },
+		"JSONBuffered": func(c *router.Context, v interface{}) { c.JSONBuffered(200, v) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(t, func(c *router.Context) {
+				write(c, map[string]interface{}{"ok": true, "ch": make(chan int)})
+			})
+
+			if w.Code != 500 {
+				t.Fatalf("expected 500, got %d", w.Code)
+			}
+			if strings.Contains(w.Body.String(), `"ok"`) {
+				t.Fatalf("expected no partial JSON body, got %q", w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); strings.HasPrefix(ct, "application/json") {
+				t.Fatalf("expected the JSON content type not to be set, got %q", ct)
+			}
+			if cl := w.Header().Get("Content-Length"); cl != "" {
+				t.Fatalf("expected no Content-Length for the JSON body, got %q", cl)
+			}
+		})
+	}
+}
+
+func TestContextMultipartReader(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("title", "report")
+	fw, _ := mw.CreateFormFile("file", "data.csv")
+	fw.Write([]byte(strings.Repeat("a,b\n", 1000)))
+	mw.Close()
+
+	type part struct {
+		name string
+		size int
+	}
+	var parts []part
+	r := router.New()
+	r.POST("/upload", func(c *router.Context) {
+		reader, err := c.MultipartReader()
+		if err != nil {
+			t.Errorf("MultipartReader: %v", err)
+			return
+		}
+		buf := make([]byte, 512)
+		for {
+			p, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Errorf("NextPart: %v", err)
+				return
+			}
+			size := 0
+			for {
+				n, err := p.Read(buf)
+				size += n
+				if err == io.EOF {
+					break
+				}
+			}
+			parts = append(parts, part{p.FormName(), size})
+		}
+		c.Status(204)
+	})
+
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(parts) != 2 || parts[0] != (part{"title", 6}) || parts[1] != (part{"file", 4000}) {
+		t.Fatalf("unexpected parts %+v", parts)
+	}
+}
+
+type tenantKey struct{}
+
+func TestSetValueVisibleToHandler(t *testing.T) {
+	var (
+		tenant   int
+		ok       bool
+		deadline bool
+		fromCtx  interface{}
+	)
+
+	r := router.New()
+	r.Use(func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) {
+			c.SetValue(tenantKey{}, 42)
+			ctx, cancel := context.WithTimeout(c.Context(), time.Minute)
+			defer cancel()
+			c.SetContext(ctx)
+			next(c)
+		}
+	})
+	r.GET("/test", func(c *router.Context) {
+		tenant, ok = c.GetInt(tenantKey{})
+		_, deadline = c.Deadline()
+		fromCtx = c.Request.Context().Value(tenantKey{})
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+
+	if !ok || tenant != 42 {
+		t.Fatalf("expected GetInt to return 42, got %d (%v)", tenant, ok)
+	}
+	if !deadline {
+		t.Fatal("expected the derived context's deadline to be visible")
+	}
+	if fromCtx != 42 {
+		t.Fatalf("expected the value on the request context, got %v", fromCtx)
+	}
+}
+
+func TestNotModifiedSince(t *testing.T) {
+	modtime := time.Date(2025, 3, 1, 12, 0, 0, 500, time.UTC)
+	tests := []struct {
+		name   string
+		method string
+		header map[string]string
+		want   int
+	}{
+		{"no header", "GET", nil, 200},
+		{"not modified", "GET", map[string]string{"If-Modified-Since": "Sat, 01 Mar 2025 12:00:00 GMT"}, 304},
+		{"modified", "GET", map[string]string{"If-Modified-Since": "Sat, 01 Mar 2025 11:59:59 GMT"}, 200},
+		{"invalid date", "GET", map[string]string{"If-Modified-Since": "yesterday"}, 200},
+		{"If-None-Match wins", "GET", map[string]string{"If-Modified-Since": "Sat, 01 Mar 2025 12:00:00 GMT", "If-None-Match": `"v1"`}, 200},
+		{"unsafe method", "POST", map[string]string{"If-Modified-Since": "Sat, 01 Mar 2025 12:00:00 GMT"}, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := router.New()
+			r.Handle(tt.method+" /report", func(c *router.Context) {
+				c.SetLastModified(modtime)
+				if c.NotModifiedSince(modtime) {
+					c.Status(304)
+					return
+				}
+				c.Data(200, "text/plain", []byte("report"))
+			})
+			req := httptest.NewRequest(tt.method, "/report", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, w.Code)
+			}
+			if lm := w.Header().Get("Last-Modified"); lm != "Sat, 01 Mar 2025 12:00:00 GMT" {
+				t.Fatalf("expected Last-Modified to be set, got %q", lm)
+			}
+		})
+	}
+}
+
+func TestContextStoreBeyondInlineEntries(t *testing.T) {
+	const n = 10
+	var values [n]interface{}
+	var found [n]bool
+
+	w := serve(t, func(c *router.Context) {
+		for i := 0; i < n; i++ {
+			c.Set("key"+strconv.Itoa(i), i)
+		}
+		c.Set("key0", "updated")
+		c.Set("key7", "updated")
+		for i := 0; i < n; i++ {
+			values[i], found[i] = c.Get("key" + strconv.Itoa(i))
+		}
+		c.Status(204)
+	})
+	if w.Code != 204 {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+
+	for i := 0; i < n; i++ {
+		want := interface{}(i)
+		if i == 0 || i == 7 {
+			want = "updated"
+		}
+		if !found[i] || values[i] != want {
+			t.Fatalf("expected key%d to be %v, got %v (%v)", i, want, values[i], found[i])
+		}
+	}
+
+	var leaked bool
+	serve(t, func(c *router.Context) { _, leaked = c.Get("key7") })
+	if leaked {
+		t.Fatal("expected the store to be cleared between requests")
+	}
+}
+
+func TestContextStoreGoSafe(t *testing.T) {
+	const workers = 8
+	var got [workers]interface{}
+
+	serve(t, func(c *router.Context) {
+		c.GoSafe()
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				key := "worker" + strconv.Itoa(i)
+				c.Set(key, i)
+				got[i], _ = c.Get(key)
+				c.Get("worker0")
+			}(i)
+		}
+		wg.Wait()
+		c.Status(204)
+	})
+
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("expected worker%d to read its own value, got %v", i, v)
+		}
+	}
+}
+
+func TestContextCopyOutlivesHandler(t *testing.T) {
+	type result struct {
+		id, user string
+		ctxErr   error
+		writer   bool
+	}
+	release := make(chan struct{})
+	done := make(chan result)
+
+	r := router.New()
+	r.GET("/users/{id}", func(c *router.Context) {
+		c.Set("user", "u-"+c.Param("id"))
+		if c.Param("id") != "1" {
+			c.Status(204)
+			return
+		}
+
+		cp := c.Copy()
+		go func() {
+			<-release
+			user, _ := cp.Get("user")
+			done <- result{cp.Param("id"), user.(string), cp.Context().Err(), cp.Writer != nil}
+		}()
+		c.Status(202)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil).WithContext(ctx))
+	cancel()
+	// Reuse the pooled Context for another request before the copy is used
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/2", nil))
+	close(release)
+
+	got := <-done
+	if got.id != "1" || got.user != "u-1" {
+		t.Fatalf("expected the copy to keep the first request's data, got %+v", got)
+	}
+	if got.ctxErr != nil {
+		t.Fatalf("expected the copy's context not to be canceled, got %v", got.ctxErr)
+	}
+	if got.writer {
+		t.Fatal("expected the copy to have no Writer")
+	}
+}
+
+func TestDoubleStatusKeepsFirstCode(t *testing.T) {
+	var statusCode int
+	r := router.New()
+	r.GET("/test", func(c *router.Context) {
+		c.Status(201)
+		c.Status(500)
+		c.JSON(400, map[string]string{"late": "body"})
+		statusCode = c.StatusCode
+	})
+
+	var serverLog bytes.Buffer
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.ErrorLog = log.New(&serverLog, "", 0)
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 201 || statusCode != 201 {
+		t.Fatalf("expected the first status 201 to be kept, got %d (StatusCode %d)", resp.StatusCode, statusCode)
+	}
+	if strings.Contains(serverLog.String(), "superfluous") {
+		t.Fatalf("expected no superfluous WriteHeader warning from the server, got %q", serverLog.String())
+	}
+}
+
+func TestWriterHijack(t *testing.T) {
+	r := router.New()
+	r.GET("/upgrade", func(c *router.Context) {
+		hijacker, ok := c.Writer.(http.Hijacker)
+		if !ok {
+			t.Errorf("expected the writer to be an http.Hijacker, got %T", c.Writer)
+			return
+		}
+		conn, rw, err := hijacker.Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		rw.Flush()
+	})
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/upgrade")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "hijacked" {
+		t.Fatalf("expected the response written on the hijacked connection, got %q", body)
+	}
+}
+
+func TestEarlyHints(t *testing.T) {
+	const link = `</app.css>; rel=preload; as=style`
+	r := router.New()
+	r.GET("/test", func(c *router.Context) {
+		c.EarlyHints(link)
+		c.Data(200, "text/html", []byte("<html></html>"))
+		c.EarlyHints(`</late.js>; rel=preload; as=script`)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	var hints []int
+	var hintLinks []string
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			hints = append(hints, code)
+			hintLinks = append(hintLinks, header.Values("Link")...)
+			return nil
+		},
+	}
+	req, _ := http.NewRequest("GET", srv.URL+"/test", nil)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if len(hints) != 1 || hints[0] != 103 {
+		t.Fatalf("expected a single 103 response, got %v", hints)
+	}
+	if len(hintLinks) != 1 || hintLinks[0] != link {
+		t.Fatalf("expected the preload link on the 103, got %v", hintLinks)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected the final 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestRespondRenderers(t *testing.T) {
+	type item struct {
+		Name string `json:"name" xml:"name"`
+	}
+	csv := func(obj interface{}) ([]byte, error) {
+		return []byte("name\n" + obj.(item).Name + "\n"), nil
+	}
+
+	tests := []struct {
+		accept      string
+		orFail      bool
+		code        int
+		contentType string
+		body        string
+	}{
+		{"application/json", false, 200, "application/json", `"name":"gopher"`},
+		{"application/xml", false, 200, "application/xml", "<name>gopher</name>"},
+		{"text/csv", false, 200, "text/csv", "name\ngopher\n"},
+		{"text/*", true, 200, "text/csv", "name\ngopher\n"},
+		{"image/png", false, 200, "application/json", `"name":"gopher"`},
+		{"image/png", true, 406, "application/problem+json", "text/csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%v", tt.accept, tt.orFail), func(t *testing.T) {
+			r := router.New().WithRenderer("text/csv", csv)
+			r.GET("/item", func(c *router.Context) {
+				if tt.orFail {
+					c.RespondOrFail(200, item{"gopher"})
+					return
+				}
+				c.Respond(200, item{"gopher"})
+			})
+			req := httptest.NewRequest("GET", "/item", nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Fatalf("expected %d, got %d", tt.code, w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.contentType) {
+				t.Fatalf("expected content type %s, got %q", tt.contentType, ct)
+			}
+			if !strings.Contains(w.Body.String(), tt.body) {
+				t.Fatalf("expected body containing %q, got %q", tt.body, w.Body.String())
+			}
+		})
+	}
+}
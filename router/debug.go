@@ -0,0 +1,63 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+)
+
+// debugMaxBodySize is the largest request body, in bytes, included in the
+// output of the Debug middleware.
+const debugMaxBodySize = 4 << 10
+
+// Debug returns middleware that writes a dump of every request and its
+// outcome to w, for local debugging. The dump holds the request line and
+// headers, the request body when its declared length is at most 4KB, and the
+// response status and duration. The body is buffered with RawBody, so the
+// handler can still read it. Debug must not be used in production, since the
+// dump contains credentials such as Authorization headers.
+func Debug(w io.Writer) MiddlewareFunc {
+	var mu sync.Mutex
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			var buf bytes.Buffer
+			dump, err := httputil.DumpRequest(c.Request, false)
+			if err != nil {
+				fmt.Fprintf(&buf, "--> %s %s (dump failed: %v)\n", c.Request.Method, c.Request.URL, err)
+			} else {
+				buf.WriteString("--> ")
+				buf.Write(bytes.ReplaceAll(bytes.TrimRight(dump, "\r\n"), []byte("\r\n"), []byte("\n")))
+				buf.WriteString("\n")
+			}
+
+			switch length := c.Request.ContentLength; {
+			case length > 0 && length <= debugMaxBodySize:
+				if body, err := c.RawBody(); err == nil {
+					buf.WriteString("\n")
+					buf.Write(body)
+					buf.WriteString("\n")
+				}
+			case length > debugMaxBodySize || length < 0:
+				buf.WriteString("\n(body omitted)\n")
+			}
+
+			var rw *responseWriter
+			restore := c.WrapWriter(func(w http.ResponseWriter) http.ResponseWriter {
+				rw = newResponseWriter(w, false)
+				return rw
+			})
+			next(c)
+			restore()
+
+			fmt.Fprintf(&buf, "<-- %d %s (%s)\n\n", rw.status, http.StatusText(rw.status), c.Elapsed())
+
+			mu.Lock()
+			w.Write(buf.Bytes())
+			mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package router_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+func TestDebug(t *testing.T) {
+	var out bytes.Buffer
+	var bound map[string]string
+
+	r := router.New()
+	r.Use(router.Debug(&out))
+	r.POST("/orders", func(c *router.Context) {
+		c.BindJSON(&bound)
+		c.Status(201)
+	})
+
+	req := httptest.NewRequest("POST", "/orders?dry=1", strings.NewReader(`{"item":"book"}`))
+	req.Header.Set("X-Request-Id", "abc123")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	dump := out.String()
+	for _, want := range []string{"--> POST /orders?dry=1 HTTP/1.1", "X-Request-Id: abc123", `{"item":"book"}`, "<-- 201 Created"} {
+		if !strings.Contains(dump, want) {
+			t.Fatalf("expected dump to contain %q, got:\n%s", want, dump)
+		}
+	}
+	if bound["item"] != "book" {
+		t.Fatalf("expected the handler to still read the body, got %v", bound)
+	}
+}
@@ -0,0 +1,61 @@
+package router
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/joakimcarlsson/go-router/docs"
+)
+
+// HealthCheck is a named check of a dependency of the service.
+type HealthCheck struct {
+	// Name identifies the check in the results, such as "database"
+	Name string
+	// Check returns a non-nil error describing the problem when the
+	// dependency is unhealthy
+	Check func(ctx context.Context) error
+}
+
+// HealthStatus is the response body of a health endpoint.
+type HealthStatus struct {
+	// Status is "ok" when every check passed and "unavailable" otherwise
+	Status string `json:"status"`
+	// Checks holds the result of each check, in registration order
+	Checks []HealthCheckResult `json:"checks,omitempty"`
+}
+
+// HealthCheckResult is the result of a single health check.
+type HealthCheckResult struct {
+	// Name is the name of the check
+	Name string `json:"name"`
+	// Status is "ok" or "fail"
+	Status string `json:"status"`
+	// Error describes why the check failed
+	Error string `json:"error,omitempty"`
+}
+
+// Health registers a GET endpoint at path that runs the given checks with the
+// request context and reports their results as JSON, in the order given. It
+// responds with 200 when all checks pass and 503 Service Unavailable when any
+// fails, which makes it suitable for liveness and readiness probes. The
+// endpoint is excluded from the API documentation.
+func (r *Router) Health(path string, checks ...HealthCheck) {
+	r.GET(path, func(c *Context) {
+		status := HealthStatus{Status: "ok"}
+		code := http.StatusOK
+
+		for _, check := range checks {
+			result := HealthCheckResult{Name: check.Name, Status: "ok"}
+			if err := check.Check(c.Context()); err != nil {
+				result.Status = "fail"
+				result.Error = err.Error()
+				status.Status = "unavailable"
+				code = http.StatusServiceUnavailable
+			}
+			status.Checks = append(status.Checks, result)
+		}
+
+		c.SetHeader("Cache-Control", "no-store")
+		c.JSON(code, status)
+	}, docs.WithExcludeFromDocs())
+}
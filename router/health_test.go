@@ -0,0 +1,56 @@
+package router_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+func TestHealth(t *testing.T) {
+	pass := router.HealthCheck{Name: "cache", Check: func(context.Context) error { return nil }}
+	fail := router.HealthCheck{Name: "database", Check: func(context.Context) error { return errors.New("database unreachable") }}
+
+	tests := []struct {
+		name   string
+		checks []router.HealthCheck
+		code   int
+		status string
+	}{
+		{"passing", []router.HealthCheck{pass}, 200, "ok"},
+		{"failing", []router.HealthCheck{pass, fail}, 503, "unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := router.New()
+			r.Health("/health", tt.checks...)
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+			if rec.Code != tt.code {
+				t.Fatalf("expected %d, got %d", tt.code, rec.Code)
+			}
+
+			var body router.HealthStatus
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Status != tt.status || len(body.Checks) != len(tt.checks) {
+				t.Fatalf("unexpected body %+v", body)
+			}
+			if body.Checks[0].Name != "cache" || body.Checks[0].Status != "ok" {
+				t.Fatalf("expected the first check's result first, got %+v", body.Checks[0])
+			}
+			if tt.code == 503 && (body.Checks[1].Name != "database" || body.Checks[1].Status != "fail" || body.Checks[1].Error != "database unreachable") {
+				t.Fatalf("expected the failing check's detail, got %+v", body.Checks[1])
+			}
+			if !r.Routes()[0].Metadata.ExcludeFromDocs {
+				t.Fatal("expected the health route to be excluded from docs")
+			}
+		})
+	}
+}
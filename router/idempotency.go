@@ -0,0 +1,183 @@
+package router
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader is the request header read by the Idempotency middleware.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// StoredResponse is a response recorded by the Idempotency middleware
+// so that it can be replayed for a repeated request.
+type StoredResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// IdempotencyStore persists responses by idempotency key.
+// Implementations are responsible for expiring entries, which allows
+// backends such as Redis to rely on their native TTL support.
+type IdempotencyStore interface {
+	// Get returns the response stored for key, if it has not expired.
+	Get(key string) (*StoredResponse, bool)
+	// Reserve marks key as in flight while its request is handled. It
+	// reports false if key is already reserved or has a stored response,
+	// and must be atomic, such as SET NX in Redis.
+	Reserve(key string) bool
+	// Set stores the response for key and ends its reservation.
+	Set(key string, response *StoredResponse)
+	// Release ends the reservation of key without storing a response.
+	Release(key string)
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore that keeps
+// each response for a fixed TTL. It is safe for concurrent use.
+type MemoryIdempotencyStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	entries  map[string]memoryEntry
+	inFlight map[string]struct{}
+}
+
+type memoryEntry struct {
+	response *StoredResponse
+	expires  time.Time
+}
+
+// NewMemoryIdempotencyStore creates an in-memory store whose entries expire after ttl.
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		ttl:      ttl,
+		entries:  make(map[string]memoryEntry),
+		inFlight: make(map[string]struct{}),
+	}
+}
+
+// Get returns the response stored for key, if it has not expired.
+func (s *MemoryIdempotencyStore) Get(key string) (*StoredResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(s.entries, key)
+		return nil, false
+	}
+	return entry.response, true
+}
+
+// Reserve marks key as in flight, reporting false if it already is or has
+// a response that has not expired.
+func (s *MemoryIdempotencyStore) Reserve(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.inFlight[key]; ok {
+		return false
+	}
+	if entry, ok := s.entries[key]; ok && !time.Now().After(entry.expires) {
+		return false
+	}
+	s.inFlight[key] = struct{}{}
+	return true
+}
+
+// Release ends the reservation of key.
+func (s *MemoryIdempotencyStore) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.inFlight, key)
+}
+
+// Set stores the response for key, ends its reservation and removes
+// expired entries.
+func (s *MemoryIdempotencyStore) Set(key string, response *StoredResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.inFlight, key)
+	now := time.Now()
+	for k, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, k)
+		}
+	}
+	s.entries[key] = memoryEntry{response: response, expires: now.Add(s.ttl)}
+}
+
+// Idempotency returns middleware that makes unsafe operations safe to retry.
+// When a request carries an Idempotency-Key header that was seen before for
+// the same method and path, the stored response is replayed instead of
+// invoking the handler again. Requests without the header pass through.
+// The key is reserved while its request is handled, so a concurrent request
+// with the same key is answered with 409 Conflict instead of running the
+// handler twice. Server errors (5xx) are not stored so that the client can
+// retry them.
+func Idempotency(store IdempotencyStore) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			key := c.GetHeader(IdempotencyKeyHeader)
+			if key == "" {
+				next(c)
+				return
+			}
+			key = c.Request.Method + " " + c.Request.URL.Path + " " + key
+
+			if stored, ok := store.Get(key); ok {
+				replay(c, stored)
+				return
+			}
+			if !store.Reserve(key) {
+				// The request may have completed since Get
+				if stored, ok := store.Get(key); ok {
+					replay(c, stored)
+					return
+				}
+				c.Problem(http.StatusConflict, ConflictProblem(
+					"A request with this idempotency key is already being processed"))
+				return
+			}
+			stored := false
+			defer func() {
+				if !stored {
+					store.Release(key)
+				}
+			}()
+
+			var rw *responseWriter
+			restore := c.WrapWriter(func(w http.ResponseWriter) http.ResponseWriter {
+				rw = newResponseWriter(w, true)
+				return rw
+			})
+			next(c)
+			restore()
+
+			if rw.status >= http.StatusInternalServerError {
+				return
+			}
+			stored = true
+			store.Set(key, &StoredResponse{
+				StatusCode: rw.status,
+				Header:     c.Writer.Header().Clone(),
+				Body:       rw.body.Bytes(),
+			})
+		}
+	}
+}
+
+// replay writes a stored response.
+func replay(c *Context, stored *StoredResponse) {
+	h := c.Writer.Header()
+	for k, v := range stored.Header {
+		h[k] = append([]string(nil), v...)
+	}
+	c.Status(stored.StatusCode)
+	c.Writer.Write(stored.Body)
+}
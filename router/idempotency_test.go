@@ -0,0 +1,96 @@
+package router_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	calls := 0
+	r := router.New()
+	r.Use(router.Idempotency(router.NewMemoryIdempotencyStore(time.Minute)))
+	r.POST("/payments", func(c *router.Context) {
+		calls++
+		c.JSON(201, map[string]int{"payment": calls})
+	})
+
+	send := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/payments", nil)
+		if key != "" {
+			req.Header.Set(router.IdempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("abc")
+	second := send("abc")
+
+	if calls != 1 {
+		t.Fatalf("expected a single handler invocation, got %d", calls)
+	}
+	if second.Code != 201 || second.Body.String() != first.Body.String() {
+		t.Fatalf("expected replayed response %d %q, got %d %q",
+			first.Code, first.Body.String(), second.Code, second.Body.String())
+	}
+	if ct := second.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Fatalf("expected replayed Content-Type, got %q", ct)
+	}
+
+	send("def")
+	send("")
+	if calls != 3 {
+		t.Fatalf("expected new or missing keys to invoke the handler, got %d calls", calls)
+	}
+}
+
+func TestIdempotencyRejectsConcurrentRequest(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	calls := 0
+	r := router.New()
+	r.Use(router.Idempotency(router.NewMemoryIdempotencyStore(time.Minute)))
+	r.POST("/payments", func(c *router.Context) {
+		calls++
+		close(entered)
+		<-release
+		c.JSON(201, map[string]int{"payment": calls})
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/payments", nil)
+		req.Header.Set(router.IdempotencyKeyHeader, "abc")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- send() }()
+	<-entered
+
+	if concurrent := send(); concurrent.Code != 409 {
+		t.Fatalf("expected 409 while the first request is in flight, got %d", concurrent.Code)
+	}
+	close(release)
+	first := <-done
+
+	if retry := send(); retry.Code != 201 || retry.Body.String() != first.Body.String() {
+		t.Fatalf("expected the stored response to be replayed, got %d %q", retry.Code, retry.Body.String())
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single handler invocation, got %d", calls)
+	}
+}
+
+func TestMemoryIdempotencyStoreExpires(t *testing.T) {
+	store := router.NewMemoryIdempotencyStore(time.Millisecond)
+	store.Set("key", &router.StoredResponse{StatusCode: 200})
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := store.Get("key"); ok {
+		t.Fatal("expected the entry to expire after the TTL")
+	}
+}
@@ -0,0 +1,30 @@
+package router
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Maintenance returns middleware that answers requests with 503 Service
+// Unavailable and a Retry-After header while isDown reports true.
+// Routes whose registered pattern is listed in exempt, such as a health
+// endpoint or the endpoint that ends maintenance, keep working.
+// The Retry-After value is retryAfter rounded down to whole seconds.
+func Maintenance(isDown func() bool, retryAfter time.Duration, exempt ...string) MiddlewareFunc {
+	seconds := strconv.Itoa(int(retryAfter.Seconds()))
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if !isDown() || slices.Contains(exempt, c.RoutePattern()) {
+				next(c)
+				return
+			}
+
+			c.SetHeader("Retry-After", seconds)
+			c.Problem(http.StatusServiceUnavailable, ServiceUnavailableProblem(
+				"the service is down for maintenance"))
+		}
+	}
+}
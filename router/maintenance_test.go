@@ -0,0 +1,48 @@
+package router_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+func TestMaintenance(t *testing.T) {
+	down := false
+	r := router.New()
+	r.Use(router.Maintenance(func() bool { return down }, 2*time.Minute, "/maintenance/end"))
+	r.GET("/status", func(c *router.Context) { c.Status(200) })
+	r.POST("/maintenance/end", func(c *router.Context) {
+		down = false
+		c.Status(200)
+	})
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("GET", "/status", nil))
+		return rec
+	}
+
+	if rec := get(); rec.Code != 200 {
+		t.Fatalf("expected 200 while up, got %d", rec.Code)
+	}
+
+	down = true
+	rec := get()
+	if rec.Code != 503 {
+		t.Fatalf("expected 503 during maintenance, got %d", rec.Code)
+	}
+	if ra := rec.Header().Get("Retry-After"); ra != "120" {
+		t.Fatalf("expected Retry-After 120, got %q", ra)
+	}
+
+	end := httptest.NewRecorder()
+	r.ServeHTTP(end, httptest.NewRequest("POST", "/maintenance/end", nil))
+	if end.Code != 200 {
+		t.Fatalf("expected the exempt route to work during maintenance, got %d", end.Code)
+	}
+	if rec := get(); rec.Code != 200 {
+		t.Fatalf("expected 200 after maintenance ended, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,166 @@
+package router
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/joakimcarlsson/go-router/jsonpatch"
+)
+
+// MergePatchContentType is the media type of JSON merge patch documents (RFC 7386).
+const MergePatchContentType = "application/merge-patch+json"
+
+// ApplyMergePatch applies the request body as a JSON merge patch (RFC 7386)
+// to original, which must be a non-nil pointer, typically to the stored
+// resource a PATCH request updates. Members of the patch replace those of
+// the resource, nested objects are merged recursively and members set to
+// null are removed, leaving the corresponding fields at their zero value:
+//
+//	todo := store.Get(id)
+//	if err := c.ApplyMergePatch(&todo); err != nil {
+//	    c.Error(http.StatusBadRequest, err.Error())
+//	    return
+//	}
+//	store.Put(id, todo)
+//
+// The patch is applied to the JSON form of original. Fields that are not
+// serialized, such as those tagged json:"-", keep their value.
+// Returns ErrEmptyBody if the request has no body. If the patch does not
+// apply, original is left unchanged, and a value of the wrong type is
+// reported as a *BindError naming the field. When the router is configured
+// with WithDisallowUnknownFields, members of the patch without a matching
+// field are an error.
+func (c *Context) ApplyMergePatch(original interface{}) error {
+	target := reflect.ValueOf(original)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return errors.New("router: ApplyMergePatch requires a non-nil pointer")
+	}
+	body, err := c.RawBody()
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ErrEmptyBody
+	}
+
+	var patch interface{}
+	if err := decodeJSONNumbers(bytes.NewReader(body), &patch); err != nil {
+		return bindError(err)
+	}
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		return err
+	}
+	var document interface{}
+	if err := decodeJSONNumbers(bytes.NewReader(data), &document); err != nil {
+		return err
+	}
+	merged, err := json.Marshal(mergePatch(document, patch))
+	if err != nil {
+		return err
+	}
+
+	// Decode into a copy of a struct with its serialized fields cleared, so
+	// removed members end up as zero fields and the others are kept
+	patched := reflect.New(target.Elem().Type())
+	if patched.Elem().Kind() == reflect.Struct {
+		patched.Elem().Set(target.Elem())
+		clearJSONFields(patched.Elem())
+	}
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	if c.disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(patched.Interface()); err != nil {
+		// Offsets point into the merged document, not the request body
+		return bindErrorAt(err, -1)
+	}
+	target.Elem().Set(patched.Elem())
+	return nil
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// clearJSONFields zeroes the fields of the struct v that appear in its JSON
+// form. Nested structs are cleared field by field, so fields tagged
+// json:"-" and unexported fields keep their value at every level.
+func clearJSONFields(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name == "-" {
+			continue
+		}
+		value := v.Field(i)
+		if !value.CanSet() {
+			continue
+		}
+		ptr := reflect.PointerTo(field.Type)
+		if field.Type.Kind() == reflect.Struct && !ptr.Implements(jsonUnmarshalerType) && !ptr.Implements(textUnmarshalerType) {
+			clearJSONFields(value)
+			continue
+		}
+		value.SetZero()
+	}
+}
+
+// BindJSONPatch6902 decodes the request body as a JSON Patch (RFC 6902), the
+// application/json-patch+json alternative to merge patches, and checks that
+// its operations are well-formed. Apply the patch to the JSON form of the
+// resource:
+//
+//	patch, err := c.BindJSONPatch6902()
+//	if err != nil {
+//	    c.Error(http.StatusBadRequest, err.Error())
+//	    return
+//	}
+//	patched, err := patch.Apply(document)
+//
+// Returns ErrEmptyBody if the request has no body.
+func (c *Context) BindJSONPatch6902() (jsonpatch.Patch, error) {
+	body, err := c.RawBody()
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, ErrEmptyBody
+	}
+	return jsonpatch.Decode(body)
+}
+
+// decodeJSONNumbers decodes JSON keeping numbers as json.Number, so large
+// integers survive the round trip through a generic document.
+func decodeJSONNumbers(r io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// mergePatch applies patch to target as described by RFC 7386.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{}, len(patchObject))
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergePatch(targetObject[name], value)
+	}
+	return targetObject
+}
@@ -0,0 +1,130 @@
+package router_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/go-router/jsonpatch"
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+type patchAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+}
+
+type patchProfile struct {
+	Name    string            `json:"name"`
+	Email   string            `json:"email,omitempty"`
+	Age     int64             `json:"age"`
+	Address *patchAddress     `json:"address,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	// PasswordHash is never serialized and must survive every patch
+	PasswordHash string `json:"-"`
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	original := func() patchProfile {
+		return patchProfile{
+			Name:    "Ada",
+			Email:   "ada@example.com",
+			Age:     9007199254740993,
+			Address: &patchAddress{Street: "1 Main St", City: "London"},
+			Labels:  map[string]string{"team": "core", "tier": "gold"},
+
+			PasswordHash: "$2a$10$hash",
+		}
+	}
+
+	tests := []struct {
+		name  string
+		patch string
+		want  func(p *patchProfile)
+	}{
+		{
+			"updates fields",
+			`{"name":"Grace"}`,
+			func(p *patchProfile) { p.Name = "Grace" },
+		},
+		{
+			"null removes fields",
+			`{"email":null,"address":null}`,
+			func(p *patchProfile) { p.Email, p.Address = "", nil },
+		},
+		{
+			"merges nested objects",
+			`{"address":{"city":"Paris"},"labels":{"tier":null,"region":"eu"}}`,
+			func(p *patchProfile) {
+				p.Address.City = "Paris"
+				p.Labels = map[string]string{"team": "core", "region": "eu"}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := original()
+			var err error
+			r := router.New()
+			r.PATCH("/profile", func(c *router.Context) { err = c.ApplyMergePatch(&profile) })
+			req := httptest.NewRequest("PATCH", "/profile", strings.NewReader(tt.patch))
+			req.Header.Set("Content-Type", router.MergePatchContentType)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := original()
+			tt.want(&want)
+			if !reflect.DeepEqual(profile, want) {
+				t.Fatalf("expected %+v, got %+v", want, profile)
+			}
+		})
+	}
+}
+
+func TestApplyMergePatchInvalidKeepsOriginal(t *testing.T) {
+	profile := patchProfile{Name: "Ada", Age: 36}
+	var err error
+	r := router.New()
+	r.PATCH("/profile", func(c *router.Context) { err = c.ApplyMergePatch(&profile) })
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PATCH", "/profile", strings.NewReader(`{"name":"Grace","age":"old"}`)))
+
+	if err == nil {
+		t.Fatal("expected an error for a patch of the wrong type")
+	}
+	if profile.Name != "Ada" || profile.Age != 36 {
+		t.Fatalf("expected the original to be unchanged, got %+v", profile)
+	}
+}
+
+func TestBindJSONPatch6902(t *testing.T) {
+	var patched []byte
+	var bindErr error
+	r := router.New()
+	r.PATCH("/todos/1", func(c *router.Context) {
+		patch, err := c.BindJSONPatch6902()
+		if bindErr = err; err != nil {
+			return
+		}
+		patched, bindErr = patch.Apply([]byte(`{"title":"Groceries","done":false}`))
+	})
+
+	req := httptest.NewRequest("PATCH", "/todos/1", strings.NewReader(`[{"op":"replace","path":"/done","value":true}]`))
+	req.Header.Set("Content-Type", jsonpatch.ContentType)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if bindErr != nil {
+		t.Fatal(bindErr)
+	}
+	if string(patched) != `{"done":true,"title":"Groceries"}` {
+		t.Fatalf("expected the patch to be applied, got %s", patched)
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PATCH", "/todos/1", nil))
+	if !errors.Is(bindErr, router.ErrEmptyBody) {
+		t.Fatalf("expected ErrEmptyBody, got %v", bindErr)
+	}
+}
@@ -0,0 +1,45 @@
+package router
+
+import "time"
+
+// NamedMiddleware gives a middleware a name used to identify it in
+// Context.MiddlewareTimings when profiling is enabled on the router.
+// The recorded time is the time spent in the middleware itself,
+// excluding the handlers further down the chain.
+func NamedMiddleware(name string, mw MiddlewareFunc) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		wrapped := mw(func(c *Context) {
+			if !c.profileMiddleware {
+				next(c)
+				return
+			}
+			start := time.Now()
+			next(c)
+			c.middlewareTimings[name] -= time.Since(start)
+		})
+
+		return func(c *Context) {
+			if !c.profileMiddleware {
+				wrapped(c)
+				return
+			}
+			if c.middlewareTimings == nil {
+				c.middlewareTimings = make(map[string]time.Duration)
+			}
+			start := time.Now()
+			wrapped(c)
+			c.middlewareTimings[name] += time.Since(start)
+		}
+	}
+}
+
+// MiddlewareTimings returns the time spent in each named middleware that has
+// completed so far for the current request. It returns an empty map unless
+// profiling is enabled with Router.WithMiddlewareProfiling.
+func (c *Context) MiddlewareTimings() map[string]time.Duration {
+	timings := make(map[string]time.Duration, len(c.middlewareTimings))
+	for name, d := range c.middlewareTimings {
+		timings[name] = d
+	}
+	return timings
+}
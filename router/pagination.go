@@ -0,0 +1,73 @@
+package router
+
+import (
+	"strconv"
+)
+
+// Default page sizes used by Paginate when PageDefaults leaves them unset.
+const (
+	DefaultPageLimit    = 20
+	DefaultMaxPageLimit = 100
+)
+
+// PageDefaults configures how Paginate reads the "offset" and "limit"
+// query parameters.
+type PageDefaults struct {
+	// Limit is used when the request has no limit or one below 1,
+	// DefaultPageLimit if zero
+	Limit int
+	// MaxLimit is the largest limit a client may request, DefaultMaxPageLimit if zero
+	MaxLimit int
+}
+
+// Paginate reads the "offset" and "limit" query parameters of a list
+// request. Missing or invalid values fall back to the defaults, a negative
+// offset becomes 0, a limit below 1 falls back to the default limit and a
+// limit above the maximum is lowered to it.
+//
+//	offset, limit := c.Paginate(router.PageDefaults{Limit: 20, MaxLimit: 100})
+//	items, total := store.List(offset, limit)
+//	c.SetPaginationHeaders(total, offset, limit)
+//	c.JSON(http.StatusOK, items)
+func (c *Context) Paginate(defaults PageDefaults) (offset, limit int) {
+	if defaults.Limit <= 0 {
+		defaults.Limit = DefaultPageLimit
+	}
+	if defaults.MaxLimit <= 0 {
+		defaults.MaxLimit = DefaultMaxPageLimit
+	}
+
+	offset = max(c.QueryIntDefault("offset", 0), 0)
+	limit = c.QueryIntDefault("limit", defaults.Limit)
+	if limit < 1 {
+		limit = defaults.Limit
+	}
+	return offset, min(limit, defaults.MaxLimit)
+}
+
+// SetPaginationHeaders sets the X-Total-Count header to total and adds
+// RFC 8288 Link headers to the next and previous pages, when there are any.
+// The links keep the request's other query parameters, such as filters.
+func (c *Context) SetPaginationHeaders(total, offset, limit int) {
+	c.SetHeader("X-Total-Count", strconv.Itoa(total))
+	if limit < 1 {
+		return
+	}
+
+	if offset+limit < total {
+		c.AddHeader("Link", c.pageLink(offset+limit, limit, "next"))
+	}
+	if offset > 0 {
+		c.AddHeader("Link", c.pageLink(max(min(offset, total)-limit, 0), limit, "prev"))
+	}
+}
+
+// pageLink returns a Link header value for the page at offset.
+func (c *Context) pageLink(offset, limit int, rel string) string {
+	u := *c.Request.URL
+	query := u.Query()
+	query.Set("offset", strconv.Itoa(offset))
+	query.Set("limit", strconv.Itoa(limit))
+	u.RawQuery = query.Encode()
+	return "<" + u.RequestURI() + `>; rel="` + rel + `"`
+}
@@ -0,0 +1,79 @@
+package router_test
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+func TestPaginateClamping(t *testing.T) {
+	tests := []struct {
+		query         string
+		offset, limit int
+	}{
+		{"", 0, 10},
+		{"?offset=30&limit=5", 30, 5},
+		{"?offset=-5&limit=0", 0, 10},
+		{"?limit=500", 0, 50},
+		{"?offset=abc&limit=xyz", 0, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var offset, limit int
+			r := router.New()
+			r.GET("/items", func(c *router.Context) {
+				offset, limit = c.Paginate(router.PageDefaults{Limit: 10, MaxLimit: 50})
+			})
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items"+tt.query, nil))
+
+			if offset != tt.offset || limit != tt.limit {
+				t.Fatalf("expected offset %d and limit %d, got %d and %d", tt.offset, tt.limit, offset, limit)
+			}
+		})
+	}
+}
+
+func TestSetPaginationHeaders(t *testing.T) {
+	tests := []struct {
+		name                 string
+		total, offset, limit int
+		links                []string
+	}{
+		{"first page", 45, 0, 20, []string{`</items?limit=20&offset=20&status=open>; rel="next"`}},
+		{"middle page", 45, 20, 20, []string{
+			`</items?limit=20&offset=40&status=open>; rel="next"`,
+			`</items?limit=20&offset=0&status=open>; rel="prev"`,
+		}},
+		{"last page", 45, 40, 20, []string{`</items?limit=20&offset=20&status=open>; rel="prev"`}},
+		{"exact last page", 40, 20, 20, []string{`</items?limit=20&offset=0&status=open>; rel="prev"`}},
+		{"partial prev", 45, 10, 20, []string{
+			`</items?limit=20&offset=30&status=open>; rel="next"`,
+			`</items?limit=20&offset=0&status=open>; rel="prev"`,
+		}},
+		{"empty", 0, 0, 20, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := router.New()
+			r.GET("/items", func(c *router.Context) {
+				c.SetPaginationHeaders(tt.total, tt.offset, tt.limit)
+				c.Status(200)
+			})
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/items?status=open", nil))
+
+			if got := w.Header().Get("X-Total-Count"); got != strconv.Itoa(tt.total) {
+				t.Fatalf("expected X-Total-Count %d, got %q", tt.total, got)
+			}
+			links := w.Header().Values("Link")
+			if strings.Join(links, "\n") != strings.Join(tt.links, "\n") {
+				t.Fatalf("expected links %q, got %q", tt.links, links)
+			}
+		})
+	}
+}
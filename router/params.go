@@ -0,0 +1,129 @@
+package router
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/joakimcarlsson/go-router/metadata"
+)
+
+// paramConstraint describes a typed path parameter such as {id:int}.
+// The schema type is used for documentation and match validates
+// the raw value at request time.
+type paramConstraint struct {
+	schemaType string
+	match      func(string) bool
+}
+
+// paramConstraints holds the constraints supported in route patterns.
+var paramConstraints = map[string]paramConstraint{
+	"int": {
+		schemaType: "integer",
+		match: func(s string) bool {
+			_, err := strconv.ParseInt(s, 10, 64)
+			return err == nil
+		},
+	},
+	"float": {
+		schemaType: "number",
+		match: func(s string) bool {
+			_, err := strconv.ParseFloat(s, 64)
+			return err == nil
+		},
+	},
+	"bool": {
+		schemaType: "boolean",
+		match: func(s string) bool {
+			_, err := strconv.ParseBool(s)
+			return err == nil
+		},
+	},
+}
+
+// parsePathConstraints strips constraints like {id:int} from a path pattern
+// and returns the plain pattern understood by http.ServeMux together with
+// the constraint registered for each parameter name.
+// It panics if a constraint is not supported.
+func parsePathConstraints(p string) (string, map[string]paramConstraint) {
+	if !strings.Contains(p, ":") {
+		return p, nil
+	}
+
+	var b strings.Builder
+	var constraints map[string]paramConstraint
+	for {
+		start := strings.Index(p, "{")
+		if start == -1 {
+			break
+		}
+		end := strings.Index(p[start:], "}")
+		if end == -1 {
+			break
+		}
+		segment := p[start+1 : start+end]
+		if name, kind, ok := strings.Cut(segment, ":"); ok {
+			constraint, known := paramConstraints[kind]
+			if !known {
+				panic(fmt.Sprintf("unknown path parameter constraint %q for {%s}", kind, name))
+			}
+			if constraints == nil {
+				constraints = make(map[string]paramConstraint)
+			}
+			constraints[name] = constraint
+			segment = name
+		}
+		b.WriteString(p[:start])
+		b.WriteString("{" + segment + "}")
+		p = p[start+end+1:]
+	}
+	b.WriteString(p)
+	return b.String(), constraints
+}
+
+// enforcePathConstraints wraps a handler so that requests whose path
+// parameters don't satisfy their constraints go to the router's NotFound
+// handler, as if the route had not matched. It is applied inside the
+// middleware chain, so middleware such as CORS and logging also runs for
+// these responses.
+func (r *Router) enforcePathConstraints(constraints map[string]paramConstraint, next HandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		for name, constraint := range constraints {
+			if !constraint.match(c.Param(name)) {
+				r.notFoundHandler()(c)
+				return
+			}
+		}
+		next(c)
+	}
+}
+
+// addPathParameters documents every {name} segment of the route path that
+// was not already declared through a route option as a required path parameter,
+// so the generated spec never omits a path parameter. The parameter type is
+// taken from the path constraint when present and defaults to string.
+func addPathParameters(m *metadata.RouteMetadata, constraints map[string]paramConstraint) {
+	for _, name := range metadata.PathParamNames(m.Path) {
+		declared := false
+		for _, param := range m.Parameters {
+			if param.In == "path" && param.Name == name {
+				declared = true
+				break
+			}
+		}
+		if declared {
+			continue
+		}
+
+		typ := "string"
+		if constraint, ok := constraints[name]; ok {
+			typ = constraint.schemaType
+		}
+		m.Parameters = append(m.Parameters, metadata.Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   metadata.Schema{Type: typ},
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProblemDetails describes an error response as defined by RFC 7807.
+// Extensions holds additional members that are serialized alongside
+// the standard fields.
+type ProblemDetails struct {
+	// Type is a URI reference identifying the problem type
+	Type string
+	// Title is a short, human-readable summary of the problem type
+	Title string
+	// Status is the HTTP status code for this occurrence of the problem
+	Status int
+	// Detail is a human-readable explanation specific to this occurrence
+	Detail string
+	// Instance is a URI reference identifying this occurrence of the problem
+	Instance string
+	// Extensions contains additional problem-specific members
+	Extensions map[string]interface{}
+}
+
+// ProblemLocalizer adapts a problem to the request it answers, for example by
+// translating its title and detail. See Router.WithProblemLocalizer.
+type ProblemLocalizer func(c *Context, problem ProblemDetails) ProblemDetails
+
+// NewProblem creates a ProblemDetails for the given status code and detail message.
+// The title defaults to the standard status text and the type to "about:blank".
+func NewProblem(status int, detail string) ProblemDetails {
+	return ProblemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// BadRequestProblem creates a 400 Bad Request problem with the given detail.
+func BadRequestProblem(detail string) ProblemDetails {
+	return NewProblem(http.StatusBadRequest, detail)
+}
+
+// ForbiddenProblem creates a 403 Forbidden problem with the given detail.
+func ForbiddenProblem(detail string) ProblemDetails {
+	return NewProblem(http.StatusForbidden, detail)
+}
+
+// NotFoundProblem creates a 404 Not Found problem with the given detail.
+func NotFoundProblem(detail string) ProblemDetails {
+	return NewProblem(http.StatusNotFound, detail)
+}
+
+// MethodNotAllowedProblem creates a 405 Method Not Allowed problem with the given detail.
+func MethodNotAllowedProblem(detail string) ProblemDetails {
+	return NewProblem(http.StatusMethodNotAllowed, detail)
+}
+
+// ConflictProblem creates a 409 Conflict problem with the given detail.
+func ConflictProblem(detail string) ProblemDetails {
+	return NewProblem(http.StatusConflict, detail)
+}
+
+// RequestEntityTooLargeProblem creates a 413 Request Entity Too Large problem with the given detail.
+func RequestEntityTooLargeProblem(detail string) ProblemDetails {
+	return NewProblem(http.StatusRequestEntityTooLarge, detail)
+}
+
+// TooManyRequestsProblem creates a 429 Too Many Requests problem with the given detail.
+func TooManyRequestsProblem(detail string) ProblemDetails {
+	return NewProblem(http.StatusTooManyRequests, detail)
+}
+
+// InternalServerErrorProblem creates a 500 Internal Server Error problem with the given detail.
+func InternalServerErrorProblem(detail string) ProblemDetails {
+	return NewProblem(http.StatusInternalServerError, detail)
+}
+
+// ServiceUnavailableProblem creates a 503 Service Unavailable problem with the given detail.
+func ServiceUnavailableProblem(detail string) ProblemDetails {
+	return NewProblem(http.StatusServiceUnavailable, detail)
+}
+
+// With adds an extension member to the problem.
+// Returns the ProblemDetails for method chaining.
+func (p ProblemDetails) With(key string, value interface{}) ProblemDetails {
+	extensions := make(map[string]interface{}, len(p.Extensions)+1)
+	for k, v := range p.Extensions {
+		extensions[k] = v
+	}
+	extensions[key] = value
+	p.Extensions = extensions
+	return p
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// Extension members are merged into the top-level object; the standard
+// fields take precedence over extensions with the same name.
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	out := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		out[k] = v
+	}
+	if p.Type != "" {
+		out["type"] = p.Type
+	}
+	if p.Title != "" {
+		out["title"] = p.Title
+	}
+	if p.Status != 0 {
+		out["status"] = p.Status
+	}
+	if p.Detail != "" {
+		out["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		out["instance"] = p.Instance
+	}
+	return json.Marshal(out)
+}
+
+// Problem sends an RFC 7807 problem details response with the given status code.
+// The Content-Type header is set to "application/problem+json" and the problem's
+// Status field is set to the status code. A localizer set with
+// Router.WithProblemLocalizer is applied before the problem is written.
+func (c *Context) Problem(status int, problem ProblemDetails) {
+	problem.Status = status
+	if problem.Title == "" {
+		problem.Title = http.StatusText(status)
+	}
+	if c.problemLocalizer != nil {
+		problem = c.problemLocalizer(c, problem)
+	}
+
+	data, err := json.Marshal(problem)
+	if err != nil {
+		http.Error(c.Writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	c.Data(status, "application/problem+json", data)
+}
@@ -0,0 +1,109 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/joakimcarlsson/go-router/docs"
+)
+
+// ProxyOption configures a route registered with Router.Proxy.
+type ProxyOption func(*proxyConfig)
+
+// proxyConfig holds the settings of a proxied route.
+type proxyConfig struct {
+	headers map[string]string
+	remove  []string
+	timeout time.Duration
+}
+
+// WithProxyHeader sets a header on every request forwarded upstream,
+// replacing any value sent by the client.
+func WithProxyHeader(key, value string) ProxyOption {
+	return func(c *proxyConfig) {
+		c.headers[key] = value
+	}
+}
+
+// WithoutProxyHeader removes a header from every request forwarded upstream,
+// for example to avoid leaking credentials meant for the gateway.
+func WithoutProxyHeader(key string) ProxyOption {
+	return func(c *proxyConfig) {
+		c.remove = append(c.remove, key)
+	}
+}
+
+// WithProxyTimeout limits how long a forwarded request may take.
+// Requests that time out are answered with 504 Gateway Timeout.
+func WithProxyTimeout(d time.Duration) ProxyOption {
+	return func(c *proxyConfig) {
+		c.timeout = d
+	}
+}
+
+// Proxy forwards every request below prefix to the upstream at targetBaseURL.
+// The matched prefix is stripped, so with a prefix of "/legacy" and a target
+// of "http://upstream/api", a request to "/legacy/users" is forwarded to
+// "http://upstream/api/users". The router's middleware applies to the proxied
+// routes, which are excluded from the API documentation.
+// It panics if targetBaseURL is not a valid absolute URL.
+func (r *Router) Proxy(prefix, targetBaseURL string, opts ...ProxyOption) {
+	target, err := url.Parse(targetBaseURL)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		panic(fmt.Sprintf("router: invalid proxy target %q", targetBaseURL))
+	}
+
+	cfg := &proxyConfig{headers: make(map[string]string)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL.Path = "/" + pr.In.PathValue("path")
+			pr.Out.URL.RawPath = ""
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			for _, key := range cfg.remove {
+				pr.Out.Header.Del(key)
+			}
+			for key, value := range cfg.headers {
+				pr.Out.Header.Set(key, value)
+			}
+		},
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			if errors.Is(err, context.DeadlineExceeded) {
+				w.WriteHeader(http.StatusGatewayTimeout)
+				return
+			}
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+
+	handler := func(c *Context) {
+		req := c.Request
+		if cfg.timeout > 0 {
+			ctx, cancel := context.WithTimeout(req.Context(), cfg.timeout)
+			defer cancel()
+			req = req.WithContext(ctx)
+		}
+		proxy.ServeHTTP(c.Writer, req)
+	}
+
+	prefix = strings.TrimSuffix(prefix, "/")
+	methods := make([]string, 0, len(routeMethods))
+	for method := range routeMethods {
+		methods = append(methods, method)
+	}
+	slices.Sort(methods)
+	for _, method := range methods {
+		r.handleMethod(method, prefix+"/{path...}", handler, []RouteOption{docs.WithExcludeFromDocs()})
+	}
+}
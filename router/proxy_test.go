@@ -0,0 +1,63 @@
+package router_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+func TestProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.Header().Set("X-Upstream-Path", r.URL.Path)
+		w.Header().Set("X-Upstream-Auth", r.Header.Get("Authorization"))
+		w.Header().Set("X-Upstream-Gateway", r.Header.Get("X-Gateway"))
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, "from upstream")
+	}))
+	defer upstream.Close()
+
+	middlewareRan := false
+	r := router.New()
+	r.Use(func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) {
+			middlewareRan = true
+			next(c)
+		}
+	})
+	r.Proxy("/legacy", upstream.URL+"/api",
+		router.WithProxyHeader("X-Gateway", "go-router"),
+		router.WithoutProxyHeader("Authorization"),
+		router.WithProxyTimeout(20*time.Millisecond),
+	)
+
+	req := httptest.NewRequest("POST", "/legacy/users/7", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTeapot || rec.Body.String() != "from upstream" {
+		t.Fatalf("expected the upstream response to pass through, got %d %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("X-Upstream-Path"); got != "/api/users/7" {
+		t.Fatalf("expected the prefix to be replaced by the target path, got %q", got)
+	}
+	if rec.Header().Get("X-Upstream-Auth") != "" || rec.Header().Get("X-Upstream-Gateway") != "go-router" {
+		t.Fatalf("expected headers to be rewritten, got %v", rec.Header())
+	}
+	if !middlewareRan {
+		t.Fatal("expected router middleware to apply to proxied routes")
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/legacy/slow", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504 on upstream timeout, got %d", rec.Code)
+	}
+}
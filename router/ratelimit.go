@@ -0,0 +1,123 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitTierAnnotation is the route annotation, set with docs.WithAnnotation,
+// that selects the rate limit tier of a route.
+const RateLimitTierAnnotation = "rate-limit-tier"
+
+// Rate is a number of requests allowed per time window.
+type Rate struct {
+	Requests int
+	Per      time.Duration
+}
+
+// RateLimitConfig configures the RateLimit middleware.
+type RateLimitConfig struct {
+	// Default is the rate of routes without a tier or with an unknown tier.
+	// A zero Default leaves those routes unlimited.
+	Default Rate
+	// Tiers maps the names used in the rate-limit-tier annotation to rates
+	Tiers map[string]Rate
+	// Key identifies the client a request is counted for, the remote IP
+	// address if nil
+	Key func(*Context) string
+}
+
+// RateLimit returns middleware that limits how often a client may call each
+// route. The rate of a route is picked by its rate-limit-tier annotation, so
+// limits are declared on the routes themselves:
+//
+//	r.Use(router.RateLimit(router.RateLimitConfig{
+//		Default: router.Rate{Requests: 100, Per: time.Minute},
+//		Tiers:   map[string]router.Rate{"premium": {Requests: 1000, Per: time.Minute}},
+//	}))
+//	r.GET("/reports", reports, docs.WithAnnotation(router.RateLimitTierAnnotation, "premium"))
+//
+// Requests are counted per client and route in fixed windows. Requests over
+// the limit are answered with 429 Too Many Requests and a Retry-After header.
+func RateLimit(config RateLimitConfig) MiddlewareFunc {
+	key := config.Key
+	if key == nil {
+		key = remoteIP
+	}
+	limiter := &rateLimiter{windows: make(map[string]*rateWindow)}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			rate := config.Default
+			if tier, ok := c.RouteAnnotation(RateLimitTierAnnotation); ok {
+				if tierRate, ok := config.Tiers[tier]; ok {
+					rate = tierRate
+				}
+			}
+			if rate.Requests <= 0 || rate.Per <= 0 {
+				next(c)
+				return
+			}
+
+			id := c.Request.Method + " " + c.RoutePattern() + " " + key(c)
+			if retryAfter, ok := limiter.allow(id, rate, time.Now()); !ok {
+				c.SetHeader("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+				c.Problem(http.StatusTooManyRequests, TooManyRequestsProblem("rate limit exceeded"))
+				return
+			}
+			next(c)
+		}
+	}
+}
+
+// rateLimiter counts requests in fixed windows. It is safe for concurrent use.
+type rateLimiter struct {
+	mu        sync.Mutex
+	windows   map[string]*rateWindow
+	lastSweep time.Time
+}
+
+type rateWindow struct {
+	count int
+	reset time.Time
+}
+
+// allow counts a request for id and reports whether it is within the rate,
+// or how long until the window resets if it is not.
+func (l *rateLimiter) allow(id string, rate Rate, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop expired windows now and then so idle clients don't pile up
+	if now.Sub(l.lastSweep) >= time.Minute {
+		for k, w := range l.windows {
+			if !now.Before(w.reset) {
+				delete(l.windows, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	w, ok := l.windows[id]
+	if !ok || !now.Before(w.reset) {
+		w = &rateWindow{reset: now.Add(rate.Per)}
+		l.windows[id] = w
+	}
+	if w.count >= rate.Requests {
+		return w.reset.Sub(now), false
+	}
+	w.count++
+	return 0, true
+}
+
+// remoteIP returns the IP address of the client that sent the request.
+func remoteIP(c *Context) string {
+	host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		return c.Request.RemoteAddr
+	}
+	return host
+}
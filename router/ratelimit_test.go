@@ -0,0 +1,53 @@
+package router_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joakimcarlsson/go-router/docs"
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+func TestRateLimitTiers(t *testing.T) {
+	r := router.New()
+	r.Use(router.RateLimit(router.RateLimitConfig{
+		Default: router.Rate{Requests: 2, Per: time.Minute},
+		Tiers:   map[string]router.Rate{"premium": {Requests: 4, Per: time.Minute}},
+	}))
+	r.GET("/basic", func(c *router.Context) { c.Status(200) })
+	r.GET("/premium", func(c *router.Context) { c.Status(200) },
+		docs.WithAnnotation(router.RateLimitTierAnnotation, "premium"))
+
+	// allowed counts the requests to path from addr that succeed out of n
+	allowed := func(path, addr string, n int) int {
+		ok := 0
+		for i := 0; i < n; i++ {
+			req := httptest.NewRequest("GET", path, nil)
+			req.RemoteAddr = addr
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			switch rec.Code {
+			case 200:
+				ok++
+			case 429:
+				if ra := rec.Header().Get("Retry-After"); ra != "60" {
+					t.Fatalf("expected Retry-After 60, got %q", ra)
+				}
+			default:
+				t.Fatalf("unexpected status %d", rec.Code)
+			}
+		}
+		return ok
+	}
+
+	if n := allowed("/basic", "192.0.2.1:1234", 5); n != 2 {
+		t.Fatalf("expected the default tier to allow 2 requests, got %d", n)
+	}
+	if n := allowed("/premium", "192.0.2.1:1234", 5); n != 4 {
+		t.Fatalf("expected the premium tier to allow 4 requests, got %d", n)
+	}
+	if n := allowed("/basic", "192.0.2.2:1234", 1); n != 1 {
+		t.Fatalf("expected another client to have its own limit, got %d", n)
+	}
+}
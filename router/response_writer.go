@@ -0,0 +1,113 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+)
+
+// responseWriter wraps an http.ResponseWriter and records the status code
+// and, optionally, a copy of the body written through it, so middleware can
+// inspect the response after the handler has run. Every Context writes
+// through one, so a second WriteHeader is dropped instead of reaching the
+// server. It passes http.Hijacker, io.ReaderFrom and http.Pusher on to the
+// writer it wraps, so WebSocket upgrades and sendfile keep working.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	captureBody bool
+	wroteHeader bool
+}
+
+// newResponseWriter returns a responseWriter wrapping w that copies the
+// body when captureBody is set.
+func newResponseWriter(w http.ResponseWriter, captureBody bool) *responseWriter {
+	return &responseWriter{ResponseWriter: w, status: http.StatusOK, captureBody: captureBody}
+}
+
+// WriteHeader records the status code and forwards it. Informational 1xx
+// headers are forwarded without ending the header, as the server allows.
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write forwards the bytes, keeping a copy if the body is captured. Bytes
+// written after a status that doesn't allow a body, such as 204 No Content,
+// are dropped and reported with http.ErrBodyNotAllowed.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !bodyAllowed(w.status) {
+		return 0, http.ErrBodyNotAllowed
+	}
+	if w.captureBody {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends any buffered data to the client, keeping the wrapper usable
+// as an http.Flusher for streaming responses.
+func (w *responseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the wrapped writer for use with http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack lets the caller take over the connection, as WebSocket upgrades do.
+// It returns an error wrapping http.ErrNotSupported when the wrapped writer
+// can't be hijacked.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// ReadFrom copies r to the response, letting the wrapped writer use
+// sendfile when it implements io.ReaderFrom and the body isn't captured.
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok && !w.captureBody && bodyAllowed(w.status) {
+		return rf.ReadFrom(r)
+	}
+	// Hide ReadFrom so io.Copy writes through Write
+	return io.Copy(struct{ io.Writer }{w}, r)
+}
+
+// Push initiates an HTTP/2 server push when the wrapped writer supports it,
+// and returns http.ErrNotSupported otherwise.
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// bodyAllowed reports whether a response with the given status may have a
+// body.
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -57,3 +57,12 @@ func NewRoute(config RouteConfig) Route {
 		Metadata: metadata,
 	}
 }
+
+// RouteDef describes a route declaratively so that route tables can live in
+// data or be generated. It is registered with Router.Register.
+type RouteDef struct {
+	Method  string
+	Path    string
+	Handler HandlerFunc
+	Options []RouteOption
+}
@@ -0,0 +1,106 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/joakimcarlsson/go-router/metadata"
+)
+
+// applyRouteMiddleware wraps a route handler with the runtime behavior
+// requested through documentation options, so that what the docs promise
+// is also what the route does.
+func applyRouteMiddleware(m *metadata.RouteMetadata, handler HandlerFunc) HandlerFunc {
+	if m.CacheControl != "" {
+		handler = cacheControlHeader(m.CacheControl)(handler)
+	}
+	if !m.Sunset.IsZero() {
+		handler = sunsetHeaders(m.Sunset)(handler)
+	}
+	if m.MaxRequestBytes > 0 {
+		handler = maxRequestBytes(m.MaxRequestBytes)(handler)
+	}
+	return handler
+}
+
+// documentRequestLimit notes the request body size limit of the route in
+// its description.
+func documentRequestLimit(m *metadata.RouteMetadata) {
+	if m.MaxRequestBytes <= 0 {
+		return
+	}
+	note := fmt.Sprintf("Request bodies are limited to %d bytes.", m.MaxRequestBytes)
+	if m.Description != "" {
+		note = m.Description + "\n\n" + note
+	}
+	m.Description = note
+}
+
+// documentResponseHeaders documents the response headers the route sets at
+// runtime on each of its successful (2xx) responses.
+func documentResponseHeaders(m *metadata.RouteMetadata) {
+	if m.CacheControl == "" {
+		return
+	}
+	for code, response := range m.Responses {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		if response.Headers == nil {
+			response.Headers = make(map[string]metadata.Header)
+		}
+		response.Headers["Cache-Control"] = metadata.Header{
+			Description: "Caching policy of the response",
+			Schema:      metadata.Schema{Type: "string", Example: m.CacheControl},
+		}
+		m.Responses[code] = response
+	}
+}
+
+// cacheControlHeader sets the Cache-Control response header.
+func cacheControlHeader(directive string) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			c.SetHeader("Cache-Control", directive)
+			next(c)
+		}
+	}
+}
+
+// sunsetHeaders sets the Deprecation and Sunset (RFC 8594) response headers.
+func sunsetHeaders(sunset time.Time) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			setSunsetHeaders(c, sunset)
+			next(c)
+		}
+	}
+}
+
+// setSunsetHeaders sets the Deprecation and Sunset headers for a route that
+// is removed after sunset, as sunsetHeaders and deprecated versions do.
+func setSunsetHeaders(c *Context, sunset time.Time) {
+	c.SetHeader("Deprecation", "true")
+	c.SetHeader("Sunset", sunset.UTC().Format(http.TimeFormat))
+}
+
+// maxRequestBytes rejects request bodies larger than n bytes. Bodies that
+// declare a larger Content-Length are answered with 413 right away; reading
+// past the limit of any other body fails with an *http.MaxBytesError.
+func maxRequestBytes(n int64) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if c.Request.ContentLength > n {
+				c.Problem(http.StatusRequestEntityTooLarge, RequestEntityTooLargeProblem(
+					fmt.Sprintf("request body exceeds %d bytes", n)))
+				return
+			}
+			if c.Request.Body != nil && c.Request.Body != http.NoBody {
+				c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, n)
+			}
+			next(c)
+		}
+	}
+}
@@ -1,11 +1,17 @@
 package router
 
 import (
+	"context"
+	"fmt"
+	"maps"
 	"net/http"
+	"net/url"
 	"path"
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/joakimcarlsson/go-router/metadata"
 )
@@ -39,14 +45,68 @@ type Router struct {
 	mu          sync.RWMutex
 	tags        []string
 	security    []metadata.SecurityRequirement
+	// middlewareNames holds the name of each middleware added with
+	// UseNamed, or "" for unnamed middleware, for removal with Without
+	middlewareNames []string
 	// maxMultipartMemory is the max memory used to parse multipart forms in bytes
 	maxMultipartMemory int64
+	// profileMiddleware enables timing of named middleware per request
+	profileMiddleware bool
+	// disallowUnknownFields makes BindJSON reject unknown JSON object keys
+	disallowUnknownFields bool
+	// maxBodySize limits the request body size in bytes, 0 means unlimited
+	maxBodySize int64
+	// defaultTimeout is the deadline of every request's context, 0 means none
+	defaultTimeout time.Duration
+	// versions holds the API versions created with Version
+	versions map[string]*apiVersion
+	// notFound handles requests that don't match any route
+	notFound HandlerFunc
+	// notFoundRouters are the routers of the hierarchy with a NotFound
+	// handler, kept on the root router which serves unmatched requests
+	notFoundRouters []*Router
+	// renderers are the additional content types offered by Context.Respond
+	renderers []contentRenderer
+	// problemLocalizer adapts problem responses to the request, see WithProblemLocalizer
+	problemLocalizer ProblemLocalizer
+}
+
+// Option configures a Router at construction time.
+type Option func(*Router)
+
+// WithMux makes the router register its routes on the given ServeMux
+// instead of a new one, for example to share it with other handlers.
+func WithMux(mux *http.ServeMux) Option {
+	return func(r *Router) {
+		r.mux = mux
+	}
+}
+
+// WithMultipartMemory sets the maximum memory in bytes used to parse
+// multipart forms before spilling to disk. The default is 32MB.
+// Spilled files are removed once the request completes. They are always
+// written to os.TempDir: mime/multipart creates them there and a
+// multipart.FileHeader can't refer to a file elsewhere, so the directory
+// can't be set per router.
+func WithMultipartMemory(n int64) Option {
+	return func(r *Router) {
+		r.maxMultipartMemory = n
+	}
+}
+
+// WithMaxBodySize limits request bodies to n bytes. Reading past the limit
+// fails, so binding an oversized body returns an error. The default is unlimited.
+func WithMaxBodySize(n int64) Option {
+	return func(r *Router) {
+		r.maxBodySize = n
+	}
 }
 
 // New creates a new Router instance with default configuration.
+// Options can be passed to change the defaults.
 // The returned router is ready to register routes and handle HTTP requests.
-func New() *Router {
-	return &Router{
+func New(opts ...Option) *Router {
+	r := &Router{
 		mux:                http.NewServeMux(),
 		prefix:             "",
 		routes:             make([]route, 0),
@@ -54,6 +114,10 @@ func New() *Router {
 		security:           make([]metadata.SecurityRequirement, 0),
 		maxMultipartMemory: 32 << 20, // 32 MB
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // WithTags adds OpenAPI tags to a router group.
@@ -80,34 +144,187 @@ func (r *Router) WithSecurity(requirements ...map[string][]string) *Router {
 
 // Use adds middleware functions to the router.
 // Middleware functions are executed in the order they are added,
-// and apply to all routes registered after this call.
+// and apply to all routes and groups registered after this call.
+// Routes and groups registered before the call are not affected.
 func (r *Router) Use(middlewares ...MiddlewareFunc) {
 	r.middlewares = append(r.middlewares, middlewares...)
+	r.middlewareNames = append(r.middlewareNames, make([]string, len(middlewares))...)
+}
+
+// UseNamed adds a middleware under a name, like Use with NamedMiddleware,
+// so a group can later drop it with Without.
+func (r *Router) UseNamed(name string, mw MiddlewareFunc) {
+	r.middlewares = append(r.middlewares, NamedMiddleware(name, mw))
+	r.middlewareNames = append(r.middlewareNames, name)
+}
+
+// Middlewares returns the middleware applied to routes registered on the
+// router from now on, outermost first.
+func (r *Router) Middlewares() []MiddlewareFunc {
+	return slices.Clone(r.middlewares)
+}
+
+// Without removes the middleware added with UseNamed under any of the names
+// from the router, so routes registered on it from now on skip them. Called
+// on a group, it leaves the parent untouched, which makes room for public
+// endpoints in an otherwise protected tree:
+//
+//	api.UseNamed("auth", requireAuth)
+//	api.Group("/public", func(public *router.Router) {
+//		public.Without("auth")
+//		public.GET("/status", status)
+//	})
+//
+// Returns the router for method chaining.
+func (r *Router) Without(names ...string) *Router {
+	middlewares := make([]MiddlewareFunc, 0, len(r.middlewares))
+	middlewareNames := make([]string, 0, len(r.middlewareNames))
+	for i, name := range r.middlewareNames {
+		if name != "" && slices.Contains(names, name) {
+			continue
+		}
+		middlewares = append(middlewares, r.middlewares[i])
+		middlewareNames = append(middlewareNames, name)
+	}
+	r.middlewares = middlewares
+	r.middlewareNames = middlewareNames
+	return r
 }
 
 // Group creates a new router group with a specific path prefix.
 // The provided function is called with the new group as an argument,
 // allowing routes to be registered within the group.
+// Path parameters in the prefix, such as "/v1/{tenant}", are documented
+// on every route registered in the group.
+// The group inherits the middleware registered on the parent at the time
+// Group is called; middleware added to the parent later doesn't apply to it.
 func (r *Router) Group(path string, fn func(*Router)) {
 	group := &Router{
 		mux:         r.mux,
 		prefix:      r.prefix + path,
 		middlewares: slices.Clone(r.middlewares),
 		parent:      r,
-		routes:      make([]route, 0),
-		tags:        make([]string, 0),
-		security:    make([]metadata.SecurityRequirement, 0),
+
+		middlewareNames: slices.Clone(r.middlewareNames),
+		routes:          make([]route, 0),
+		tags:            make([]string, 0),
+		security:        make([]metadata.SecurityRequirement, 0),
+
+		maxMultipartMemory: r.maxMultipartMemory,
+		profileMiddleware:  r.profileMiddleware,
+		maxBodySize:        r.maxBodySize,
+		defaultTimeout:     r.defaultTimeout,
+
+		disallowUnknownFields: r.disallowUnknownFields,
+		renderers:             r.renderers,
+		problemLocalizer:      r.problemLocalizer,
 	}
 	fn(group)
 
+	// The group's routes are only recorded for documentation. Their handlers
+	// already carry the full middleware chain and were registered with the
+	// shared mux by the group itself, so they must not be wrapped or registered again.
 	r.mu.Lock()
 	r.routes = append(r.routes, group.routes...)
 	r.mu.Unlock()
 }
 
+// GroupIf creates a route group like Group if enabled is true, and does
+// nothing otherwise, so endpoints behind a feature flag or build tag are
+// neither served nor documented when it is off:
+//
+//	r.GroupIf(flags.Beta, "/beta", func(beta *router.Router) {
+//		beta.GET("/reports", listReports)
+//	})
+func (r *Router) GroupIf(enabled bool, prefix string, fn func(*Router)) {
+	if !enabled {
+		return
+	}
+	r.Group(prefix, fn)
+}
+
+// apiVersion holds the state shared by the routes of an API version.
+type apiVersion struct {
+	// sunset is set once the version is deprecated
+	sunset atomic.Pointer[time.Time]
+	// routes holds the routes registered through Version, guarded by the
+	// mutex of the router the version belongs to
+	routes []route
+}
+
+// Version creates a route group for an API version, such as "v2", under
+// the path prefix "/v2". Routes in the group are tagged with the version in
+// the documentation and respond with an X-API-Version header.
+// Version can be called several times with the same version to add routes.
+func (r *Router) Version(v string, fn func(*Router)) {
+	v = strings.Trim(v, "/")
+
+	r.mu.Lock()
+	if r.versions == nil {
+		r.versions = make(map[string]*apiVersion)
+	}
+	state, ok := r.versions[v]
+	if !ok {
+		state = &apiVersion{}
+		r.versions[v] = state
+	}
+	r.mu.Unlock()
+
+	r.Group("/"+v, func(group *Router) {
+		group.WithTags(v)
+		group.Use(func(next HandlerFunc) HandlerFunc {
+			return func(c *Context) {
+				c.SetHeader("X-API-Version", v)
+				if sunset := state.sunset.Load(); sunset != nil {
+					setSunsetHeaders(c, *sunset)
+				}
+				next(c)
+			}
+		})
+		fn(group)
+
+		r.mu.Lock()
+		state.routes = append(state.routes, group.routes...)
+		r.mu.Unlock()
+		if sunset := state.sunset.Load(); sunset != nil {
+			deprecateRoutes(group.routes, *sunset)
+		}
+	})
+}
+
+// DeprecateVersion marks every route registered through Version for the API
+// version v as deprecated in the documentation with the given sunset date.
+// The routes respond with Deprecation and Sunset headers from then on, as
+// with docs.WithSunset. Other routes under the version's path prefix are
+// left alone. It panics if the version is unknown.
+func (r *Router) DeprecateVersion(v string, sunset time.Time) {
+	v = strings.Trim(v, "/")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.versions[v]
+	if !ok {
+		panic(fmt.Sprintf("router: cannot deprecate unknown version %q", v))
+	}
+	state.sunset.Store(&sunset)
+	deprecateRoutes(state.routes, sunset)
+}
+
+// deprecateRoutes marks the routes as deprecated with the given sunset date.
+func deprecateRoutes(routes []route, sunset time.Time) {
+	for _, rt := range routes {
+		rt.metadata.Deprecated = true
+		rt.metadata.Sunset = sunset
+	}
+}
+
 // Handle registers a new route with the given pattern and handler.
 // The pattern must be in the format "METHOD /path".
+// Path parameters may carry a type constraint such as {id:int}, {price:float}
+// or {active:bool}; requests that don't satisfy it are answered with 404.
 // Route options can be provided to add OpenAPI documentation to the route.
+// A route disabled with docs.WithEnabled(false) is not registered.
 func (r *Router) Handle(pattern string, handler HandlerFunc, opts ...RouteOption) {
 	parts := strings.SplitN(pattern, " ", 2)
 	if len(parts) != 2 {
@@ -115,8 +332,7 @@ func (r *Router) Handle(pattern string, handler HandlerFunc, opts ...RouteOption
 	}
 	method, subpath := parts[0], parts[1]
 
-	fullpath := normalizePath(path.Join(r.prefix, subpath))
-	finalHandler := r.buildMiddlewareChain(handler)
+	fullpath, constraints := parsePathConstraints(normalizePath(path.Join(r.prefix, subpath)))
 
 	metadata := &metadata.RouteMetadata{
 		Method:     method,
@@ -138,52 +354,197 @@ func (r *Router) Handle(pattern string, handler HandlerFunc, opts ...RouteOption
 	for _, opt := range opts {
 		opt(metadata)
 	}
+	if metadata.Disabled {
+		return
+	}
 
-	r.mu.Lock()
-	r.routes = append(r.routes, route{
+	addPathParameters(metadata, constraints)
+	documentResponseHeaders(metadata)
+	documentRequestLimit(metadata)
+
+	finalHandler := r.chainRoute(constraints, applyRouteMiddleware(metadata, handler))
+
+	r.addRoute(route{
 		method:   method,
 		path:     fullpath,
 		handler:  finalHandler,
 		metadata: metadata,
-	})
+	}, r)
+}
+
+// Merge re-registers every route of sub under prefix on the receiver.
+// Unlike mounting a plain http.Handler, the routes keep their metadata, so
+// they show up in the generated documentation under the joined path.
+// The merged handlers keep sub's middleware and settings and are wrapped
+// with the receiver's middleware. Routes added to sub after Merge are not included.
+func (r *Router) Merge(prefix string, sub *Router) {
+	sub.mu.RLock()
+	routes := slices.Clone(sub.routes)
+	sub.mu.RUnlock()
+
+	for _, rt := range routes {
+		fullpath, constraints := parsePathConstraints(normalizePath(path.Join(r.prefix, prefix, rt.path)))
+
+		metadata := cloneMetadata(rt.metadata)
+		metadata.Path = fullpath
+		metadata.Tags = append(slices.Clone(r.tags), rt.metadata.Tags...)
+		metadata.Security = append(slices.Clone(r.security), rt.metadata.Security...)
+		addPathParameters(&metadata, constraints)
+
+		handler := r.chainRoute(constraints, rt.handler)
+
+		r.addRoute(route{
+			method:   rt.method,
+			path:     fullpath,
+			handler:  handler,
+			metadata: &metadata,
+		}, sub)
+	}
+}
+
+// cloneMetadata copies route metadata along with its maps and slices, so
+// that changes to the copy don't reach the original.
+func cloneMetadata(m *metadata.RouteMetadata) metadata.RouteMetadata {
+	clone := *m
+	clone.Tags = slices.Clone(m.Tags)
+	clone.Parameters = slices.Clone(m.Parameters)
+	clone.Security = slices.Clone(m.Security)
+	clone.Servers = slices.Clone(m.Servers)
+	clone.Annotations = maps.Clone(m.Annotations)
+	clone.Extensions = maps.Clone(m.Extensions)
+	if m.RequestBody != nil {
+		requestBody := *m.RequestBody
+		requestBody.Content = maps.Clone(requestBody.Content)
+		clone.RequestBody = &requestBody
+	}
+	if m.Responses != nil {
+		clone.Responses = make(map[string]metadata.Response, len(m.Responses))
+		for code, response := range m.Responses {
+			response.Content = maps.Clone(response.Content)
+			response.Headers = maps.Clone(response.Headers)
+			clone.Responses[code] = response
+		}
+	}
+	return clone
+}
+
+// addRoute records rt and registers it with the mux. Per-request settings
+// such as the multipart memory limit are taken from cfg.
+func (r *Router) addRoute(rt route, cfg *Router) {
+	r.mu.Lock()
+	r.routes = append(r.routes, rt)
 	r.mu.Unlock()
 
-	r.mux.HandleFunc(method+" "+fullpath, func(w http.ResponseWriter, req *http.Request) {
+	r.mux.HandleFunc(rt.method+" "+rt.path, serveRoute(rt, cfg))
+}
+
+// serveRoute adapts a route to an http.HandlerFunc that runs it with a
+// pooled Context configured from cfg.
+func serveRoute(rt route, cfg *Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if cfg.maxBodySize > 0 && req.Body != nil && req.Body != http.NoBody {
+			req.Body = http.MaxBytesReader(w, req.Body, cfg.maxBodySize)
+		}
+		if cfg.defaultTimeout > 0 && (rt.metadata == nil || !rt.metadata.NoTimeout) {
+			timeoutCtx, cancel := context.WithTimeout(req.Context(), cfg.defaultTimeout)
+			defer cancel()
+			req = req.WithContext(timeoutCtx)
+		}
 		ctx := acquireContext(w, req)
-		ctx.maxMultipartMemory = r.maxMultipartMemory
+		ctx.maxMultipartMemory = cfg.maxMultipartMemory
+		ctx.profileMiddleware = cfg.profileMiddleware
+		ctx.disallowUnknownFields = cfg.disallowUnknownFields
+		ctx.routeMetadata = rt.metadata
+		ctx.routePattern = rt.path
+		ctx.renderers = cfg.renderers
+		ctx.problemLocalizer = cfg.problemLocalizer
 		defer releaseContext(ctx)
-		finalHandler(ctx)
-	})
+		rt.handler(ctx)
+	}
+}
+
+// HandlerAdapter returns an http.Handler that runs h with the router's
+// middleware and settings, as if it were a registered route, so it can be
+// used with another mux or called directly in tests. The handler is not
+// registered on the router and has no route metadata or pattern.
+func (r *Router) HandlerAdapter(h HandlerFunc) http.Handler {
+	return serveRoute(route{handler: r.buildMiddlewareChain(h)}, r)
 }
 
 // GET registers a new GET route with the specified path and handler.
 // Options can be provided to add OpenAPI documentation to the route.
 func (r *Router) GET(path string, handler HandlerFunc, opts ...RouteOption) {
-	r.Handle("GET "+path, handler, opts...)
+	r.handleMethod("GET", path, handler, opts)
 }
 
 // POST registers a new POST route with the specified path and handler.
 // Options can be provided to add OpenAPI documentation to the route.
 func (r *Router) POST(path string, handler HandlerFunc, opts ...RouteOption) {
-	r.Handle("POST "+path, handler, opts...)
+	r.handleMethod("POST", path, handler, opts)
 }
 
 // PUT registers a new PUT route with the specified path and handler.
 // Options can be provided to add OpenAPI documentation to the route.
 func (r *Router) PUT(path string, handler HandlerFunc, opts ...RouteOption) {
-	r.Handle("PUT "+path, handler, opts...)
+	r.handleMethod("PUT", path, handler, opts)
 }
 
 // DELETE registers a new DELETE route with the specified path and handler.
 // Options can be provided to add OpenAPI documentation to the route.
 func (r *Router) DELETE(path string, handler HandlerFunc, opts ...RouteOption) {
-	r.Handle("DELETE "+path, handler, opts...)
+	r.handleMethod("DELETE", path, handler, opts)
 }
 
 // PATCH registers a new PATCH route with the specified path and handler.
 // Options can be provided to add OpenAPI documentation to the route.
 func (r *Router) PATCH(path string, handler HandlerFunc, opts ...RouteOption) {
-	r.Handle("PATCH "+path, handler, opts...)
+	r.handleMethod("PATCH", path, handler, opts)
+}
+
+// OPTIONS registers a new OPTIONS route with the specified path and handler.
+// This is useful for documenting CORS preflight endpoints explicitly.
+func (r *Router) OPTIONS(path string, handler HandlerFunc, opts ...RouteOption) {
+	r.handleMethod("OPTIONS", path, handler, opts)
+}
+
+// HEAD registers a new HEAD route with the specified path and handler.
+// Options can be provided to add OpenAPI documentation to the route.
+func (r *Router) HEAD(path string, handler HandlerFunc, opts ...RouteOption) {
+	r.handleMethod("HEAD", path, handler, opts)
+}
+
+// routeMethods lists the HTTP methods accepted by Register.
+var routeMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	http.MethodPatch:   true,
+	http.MethodOptions: true,
+	http.MethodHead:    true,
+}
+
+// Register registers every route in the table as if it had been added with
+// the matching method helper, applying its options the same way.
+// It panics on an unsupported method or an invalid path.
+func (r *Router) Register(routes []RouteDef) {
+	for _, def := range routes {
+		method := strings.ToUpper(def.Method)
+		if !routeMethods[method] {
+			panic(fmt.Sprintf("router: unsupported method %q for route %q", def.Method, def.Path))
+		}
+		r.handleMethod(method, def.Path, def.Handler, def.Options)
+	}
+}
+
+// handleMethod registers a route for one of the HTTP method helpers.
+// It panics if the path is neither empty nor starts with a slash, which
+// usually means a method or a relative path was passed by mistake.
+func (r *Router) handleMethod(method, path string, handler HandlerFunc, opts []RouteOption) {
+	if path != "" && path[0] != '/' {
+		panic(fmt.Sprintf("router: invalid path %q passed to %s, path must be empty or start with '/'", path, method))
+	}
+	r.Handle(method+" "+path, handler, opts...)
 }
 
 // WithMultipartConfig sets the maximum memory allocation for multipart form data parsing.
@@ -194,6 +555,199 @@ func (r *Router) WithMultipartConfig(maxMemory int64) *Router {
 	return r
 }
 
+// WithDisallowUnknownFields makes BindJSON fail when the request body contains
+// object keys that don't match any field of the target. This surfaces client
+// typos instead of silently dropping the data. The default is lenient.
+// The setting is read on every request, so it applies to all routes of the
+// router, including those registered earlier, and to groups created afterwards.
+func (r *Router) WithDisallowUnknownFields(disallow bool) *Router {
+	r.disallowUnknownFields = disallow
+	return r
+}
+
+// Renderer encodes a response body for a content type registered with
+// WithRenderer. Marshal functions such as yaml.Marshal can be used directly.
+type Renderer func(obj interface{}) ([]byte, error)
+
+// contentRenderer is a Renderer registered for a content type
+type contentRenderer struct {
+	contentType string
+	render      Renderer
+}
+
+// WithRenderer registers a renderer for contentType, offering it to clients
+// through Context.Respond and Context.RespondOrFail next to JSON and XML:
+//
+//	r.WithRenderer("application/yaml", yaml.Marshal)
+//
+// Registering "application/json" or "application/xml" replaces the built-in
+// encoding. Groups created afterwards inherit the renderers.
+func (r *Router) WithRenderer(contentType string, render Renderer) *Router {
+	renderers := make([]contentRenderer, 0, len(r.renderers)+1)
+	for _, existing := range r.renderers {
+		if existing.contentType != contentType {
+			renderers = append(renderers, existing)
+		}
+	}
+	r.renderers = append(renderers, contentRenderer{contentType: contentType, render: render})
+	return r
+}
+
+// WithDefaultTimeout sets a deadline on the context of every request, d
+// after the request is routed, so database calls and outgoing requests made
+// with c.Context() are canceled when it passes. The deadline is in place
+// before any middleware runs. It doesn't write a response by itself;
+// handlers see context.DeadlineExceeded from the calls they make. Routes
+// such as server-sent event streams opt out with docs.WithNoTimeout.
+// Groups created afterwards inherit the timeout.
+func (r *Router) WithDefaultTimeout(d time.Duration) *Router {
+	r.defaultTimeout = d
+	return r
+}
+
+// WithProblemLocalizer sets a function that adapts every problem response
+// sent with Context.Problem to the request before it is written, typically
+// translating its title and detail to the language the client prefers.
+// The i18n package provides one based on the Accept-Language header.
+// Groups created afterwards inherit the localizer.
+func (r *Router) WithProblemLocalizer(localize ProblemLocalizer) *Router {
+	r.problemLocalizer = localize
+	return r
+}
+
+// WithMiddlewareProfiling enables recording the wall time spent in each
+// middleware wrapped with NamedMiddleware. The timings are available to
+// handlers and outer middleware through Context.MiddlewareTimings.
+// The setting is read on every request, so it applies to all routes of the
+// router, including those registered earlier, and to groups created afterwards.
+func (r *Router) WithMiddlewareProfiling(enabled bool) *Router {
+	r.profileMiddleware = enabled
+	return r
+}
+
+// NotFound sets the handler for requests that don't match any route,
+// replacing the default 404 problem response. The router's middleware
+// applies to it, and helpers such as Static use it for missing files.
+// Set on a group, it only handles unmatched paths below the group's prefix;
+// the router with the longest matching prefix handles each request.
+// Requests with a method not registered for a path still receive 405
+// Method Not Allowed.
+func (r *Router) NotFound(handler HandlerFunc) {
+	r.mu.Lock()
+	first := r.notFound == nil
+	r.notFound = handler
+	r.mu.Unlock()
+	if !first {
+		return
+	}
+
+	// The catch-all pattern is registered once, on the root's mux
+	root := r
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.mu.Lock()
+	register := len(root.notFoundRouters) == 0
+	root.notFoundRouters = append(root.notFoundRouters, r)
+	root.mu.Unlock()
+	if register {
+		root.mux.HandleFunc("/", root.serveUnmatched)
+	}
+}
+
+// serveUnmatched handles requests that match no route. It answers with 405
+// Method Not Allowed when the path is registered for other methods, and
+// otherwise runs the NotFound handler of the router with the longest prefix
+// matching the path, through that router's middleware.
+func (r *Router) serveUnmatched(w http.ResponseWriter, req *http.Request) {
+	target := r
+	r.mu.RLock()
+	for _, rt := range r.notFoundRouters {
+		if len(rt.prefix) > len(target.prefix) && hasPathPrefix(req.URL.Path, rt.prefix) {
+			target = rt
+		}
+	}
+	r.mu.RUnlock()
+
+	handler := target.notFoundHandler()
+	if allowed := r.allowedMethods(req); len(allowed) > 0 {
+		handler = func(c *Context) {
+			c.SetHeader("Allow", strings.Join(allowed, ", "))
+			c.Problem(http.StatusMethodNotAllowed, MethodNotAllowedProblem(
+				fmt.Sprintf("method %s is not allowed for %s", c.Request.Method, c.Request.URL.Path)))
+		}
+	}
+	serveRoute(route{handler: target.buildMiddlewareChain(handler)}, target)(w, req)
+}
+
+// allowedMethods returns the methods of the routes matching the request's
+// path, as listed in the Allow header of a 405 response.
+func (r *Router) allowedMethods(req *http.Request) []string {
+	r.mu.RLock()
+	var methods []string
+	for _, rt := range r.routes {
+		if !slices.Contains(methods, rt.method) {
+			methods = append(methods, rt.method)
+		}
+	}
+	r.mu.RUnlock()
+
+	var allowed []string
+	for _, method := range methods {
+		probe := &http.Request{Method: method, Host: req.Host, URL: req.URL}
+		if _, pattern := r.mux.Handler(probe); pattern != "" && pattern != "/" {
+			allowed = append(allowed, method)
+		}
+	}
+	if slices.Contains(allowed, http.MethodGet) && !slices.Contains(allowed, http.MethodHead) {
+		allowed = append(allowed, http.MethodHead)
+	}
+	slices.Sort(allowed)
+	return allowed
+}
+
+// hasPathPrefix reports whether the path lies below the route prefix,
+// matching path parameters in the prefix, such as "{tenant}", against any
+// segment.
+func hasPathPrefix(p, prefix string) bool {
+	segments := strings.Split(strings.Trim(p, "/"), "/")
+	for i, want := range strings.Split(strings.Trim(prefix, "/"), "/") {
+		if want == "" {
+			continue
+		}
+		if i >= len(segments) || (segments[i] != want && !strings.HasPrefix(want, "{")) {
+			return false
+		}
+	}
+	return true
+}
+
+// notFoundHandler returns the NotFound handler of the router or its closest
+// parent, falling back to a 404 problem response.
+func (r *Router) notFoundHandler() HandlerFunc {
+	for rt := r; rt != nil; rt = rt.parent {
+		rt.mu.RLock()
+		handler := rt.notFound
+		rt.mu.RUnlock()
+		if handler != nil {
+			return handler
+		}
+	}
+	return func(c *Context) {
+		c.Problem(http.StatusNotFound, NotFoundProblem(c.Request.URL.Path+" was not found"))
+	}
+}
+
+// chainRoute wraps a route handler with the check of its path constraints
+// and the router's middleware. Every route is registered through it, so the
+// middleware also runs for requests that fail the constraints.
+func (r *Router) chainRoute(constraints map[string]paramConstraint, handler HandlerFunc) HandlerFunc {
+	if len(constraints) > 0 {
+		handler = r.enforcePathConstraints(constraints, handler)
+	}
+	return r.buildMiddlewareChain(handler)
+}
+
 // buildMiddlewareChain builds the middleware chain for a handler.
 // It applies each middleware in reverse order so that the first middleware
 // in the list is the outermost wrapper around the handler.
@@ -233,6 +787,27 @@ func (r *Router) Routes() []Route {
 	return routes
 }
 
+// Lookup returns the metadata of the route that would handle a request with
+// the given method and path, such as "GET" and "/users/42". Paths are matched
+// against the registered patterns the same way requests are, so the result
+// includes the documented security requirements of the matched route.
+func (r *Router) Lookup(method, path string) (*metadata.RouteMetadata, bool) {
+	req := &http.Request{Method: method, URL: &url.URL{Path: path}}
+	_, pattern := r.mux.Handler(req)
+	if pattern == "" {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rt := range r.routes {
+		if rt.method+" "+rt.path == pattern {
+			return rt.metadata, true
+		}
+	}
+	return nil, false
+}
+
 // normalizePath ensures the path starts with a slash and is cleaned.
 // It handles edge cases like empty paths and relative paths.
 func normalizePath(p string) string {
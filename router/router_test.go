@@ -3,10 +3,16 @@ package router_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
+	"mime/multipart"
+	"net/http"
 	"net/http/httptest"
+	"os"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/joakimcarlsson/go-router/docs"
 	"github.com/joakimcarlsson/go-router/router"
@@ -328,6 +334,66 @@ func BenchmarkContextOperations(b *testing.B) {
 		}
 	})
 
+	b.Run("ContextStoreGoSafe", func(b *testing.B) {
+		r := router.New()
+		r.Use(func(next router.HandlerFunc) router.HandlerFunc {
+			return func(c *router.Context) {
+				c.GoSafe()
+				c.Set("key1", "value1")
+				c.Set("key2", 123)
+				c.Set("key3", true)
+				next(c)
+			}
+		})
+
+		r.GET("/test", func(c *router.Context) {
+			val1, _ := c.Get("key1")
+			val2, _ := c.Get("key2")
+			val3, _ := c.Get("key3")
+			_ = val1
+			_ = val2
+			_ = val3
+		})
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		b.ResetTimer()
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+		}
+	})
+
+	b.Run("ContextStoreSpill", func(b *testing.B) {
+		keys := []string{"key1", "key2", "key3", "key4", "key5", "key6", "key7", "key8"}
+		r := router.New()
+		r.Use(func(next router.HandlerFunc) router.HandlerFunc {
+			return func(c *router.Context) {
+				for _, key := range keys {
+					c.Set(key, true)
+				}
+				next(c)
+			}
+		})
+
+		r.GET("/test", func(c *router.Context) {
+			for _, key := range keys {
+				val, _ := c.Get(key)
+				_ = val
+			}
+		})
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		b.ResetTimer()
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+		}
+	})
+
 	b.Run("JSONResponse", func(b *testing.B) {
 		r := router.New()
 		r.GET("/json", func(c *router.Context) {
@@ -606,3 +672,816 @@ func BenchmarkRealWorldScenario(b *testing.B) {
 func createReaderFromBytes(b []byte) io.Reader {
 	return bytes.NewReader(b)
 }
+
+func TestHandleDetectsPathParameters(t *testing.T) {
+	r := router.New()
+	r.GET("/products/{id}", func(c *router.Context) {})
+
+	params := r.Routes()[0].Metadata.Parameters
+	if len(params) != 1 {
+		t.Fatalf("expected 1 parameter, got %d", len(params))
+	}
+	if params[0].Name != "id" || params[0].In != "path" || !params[0].Required {
+		t.Fatalf("unexpected parameter: %+v", params[0])
+	}
+	if params[0].Schema.Type != "string" {
+		t.Fatalf("expected string schema, got %q", params[0].Schema.Type)
+	}
+}
+
+func TestHandleExplicitPathParameterWins(t *testing.T) {
+	r := router.New()
+	r.GET("/products/{id}", func(c *router.Context) {},
+		docs.WithPathParam("id", "integer", true, "Product ID", 1),
+	)
+
+	params := r.Routes()[0].Metadata.Parameters
+	if len(params) != 1 {
+		t.Fatalf("expected 1 parameter, got %d", len(params))
+	}
+	if params[0].Schema.Type != "integer" || params[0].Description != "Product ID" {
+		t.Fatalf("expected explicit parameter to be kept, got %+v", params[0])
+	}
+}
+
+func TestHandlePathConstraintType(t *testing.T) {
+	r := router.New()
+	r.GET("/users/{id:int}", func(c *router.Context) {
+		c.JSON(200, map[string]string{"id": c.Param("id")})
+	})
+
+	route := r.Routes()[0]
+	if route.Path != "/users/{id}" {
+		t.Fatalf("expected constraint to be stripped from path, got %q", route.Path)
+	}
+	params := route.Metadata.Parameters
+	if len(params) != 1 || params[0].Schema.Type != "integer" {
+		t.Fatalf("expected integer path parameter, got %+v", params)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/7", nil))
+	if w.Code != 200 {
+		t.Fatalf("expected 200 for numeric id, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/abc", nil))
+	if w.Code != 404 {
+		t.Fatalf("expected 404 for non-numeric id, got %d", w.Code)
+	}
+}
+
+func TestPathConstraintMismatchUsesNotFound(t *testing.T) {
+	r := router.New()
+	r.Use(func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) {
+			c.SetHeader("Access-Control-Allow-Origin", "*")
+			next(c)
+		}
+	})
+	r.NotFound(func(c *router.Context) {
+		c.JSON(404, map[string]string{"error": "not found"})
+	})
+	r.GET("/users/{id:int}", func(c *router.Context) {})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/abc", nil))
+	if w.Code != 404 || w.Body.String() != "{\"error\":\"not found\"}\n" {
+		t.Fatalf("expected the NotFound handler's response, got %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatal("expected the middleware to run for the 404")
+	}
+}
+
+func TestMethodHelperRejectsRelativePath(t *testing.T) {
+	r := router.New()
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			t.Fatal("expected GET(\"users\") to panic")
+		}
+		msg, _ := rec.(string)
+		if !strings.Contains(msg, `"users"`) || !strings.Contains(msg, "GET") {
+			t.Fatalf("expected panic message to name the path and method, got %v", rec)
+		}
+	}()
+	r.GET("users", func(c *router.Context) {})
+}
+
+func TestMethodHelperAllowsEmptyPath(t *testing.T) {
+	r := router.New()
+	r.Group("/users", func(users *router.Router) {
+		users.GET("", func(c *router.Context) {
+			c.Status(204)
+		})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+	if w.Code != 204 {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+}
+
+func TestGroupPrefixPathParameters(t *testing.T) {
+	r := router.New()
+	r.Group("/v1/{tenant}", func(v1 *router.Router) {
+		v1.GET("/users", func(c *router.Context) {})
+		v1.GET("/users/{id}", func(c *router.Context) {})
+	})
+
+	for _, route := range r.Routes() {
+		found := false
+		for _, param := range route.Metadata.Parameters {
+			if param.Name == "tenant" && param.In == "path" {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("expected %s to document the tenant path parameter", route.Path)
+		}
+	}
+}
+
+func TestOptionsAndHeadRoutes(t *testing.T) {
+	r := router.New()
+	r.OPTIONS("/users", func(c *router.Context) {
+		c.SetHeader("Allow", "GET, OPTIONS")
+		c.Status(204)
+	})
+	r.HEAD("/users", func(c *router.Context) {
+		c.Status(200)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/users", nil))
+	if w.Code != 204 || w.Header().Get("Allow") != "GET, OPTIONS" {
+		t.Fatalf("unexpected OPTIONS response: %d %v", w.Code, w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("HEAD", "/users", nil))
+	if w.Code != 200 {
+		t.Fatalf("expected 200 for HEAD, got %d", w.Code)
+	}
+
+	methods := map[string]bool{}
+	for _, route := range r.Routes() {
+		methods[route.Method] = true
+	}
+	if !methods["OPTIONS"] || !methods["HEAD"] {
+		t.Fatalf("expected OPTIONS and HEAD routes, got %v", methods)
+	}
+}
+
+func TestMiddlewareProfiling(t *testing.T) {
+	sleepy := func(d time.Duration) router.MiddlewareFunc {
+		return func(next router.HandlerFunc) router.HandlerFunc {
+			return func(c *router.Context) {
+				time.Sleep(d)
+				next(c)
+			}
+		}
+	}
+
+	var timings map[string]time.Duration
+	r := router.New().WithMiddlewareProfiling(true)
+	r.Use(func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) {
+			next(c)
+			timings = c.MiddlewareTimings()
+		}
+	})
+	r.Use(router.NamedMiddleware("auth", sleepy(5*time.Millisecond)))
+	r.Use(router.NamedMiddleware("logging", sleepy(time.Millisecond)))
+	var handlerTime time.Duration
+	r.GET("/test", func(c *router.Context) {
+		start := time.Now()
+		time.Sleep(10 * time.Millisecond)
+		handlerTime = time.Since(start)
+	})
+
+	start := time.Now()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+	elapsed := time.Since(start)
+
+	if len(timings) != 2 {
+		t.Fatalf("expected 2 timings, got %v", timings)
+	}
+	if timings["auth"] < 5*time.Millisecond {
+		t.Fatalf("expected auth timing to be recorded, got %v", timings["auth"])
+	}
+	// Timings that included the handler would add up to more than the request
+	if total := timings["auth"] + timings["logging"] + handlerTime; total > elapsed {
+		t.Fatalf("expected timings to exclude downstream time, got %v for a %v request", timings, elapsed)
+	}
+	if timings["logging"] < time.Millisecond {
+		t.Fatalf("expected logging timing to be recorded, got %v", timings["logging"])
+	}
+}
+
+func TestSunsetHeaders(t *testing.T) {
+	sunset := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	r := router.New()
+	r.GET("/v1/users", func(c *router.Context) {
+		c.Status(200)
+	}, docs.WithSunset(sunset))
+
+	if !r.Routes()[0].Metadata.Deprecated {
+		t.Fatal("expected route to be marked deprecated")
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/v1/users", nil))
+	if got := w.Header().Get("Sunset"); got != "Tue, 01 Jan 2030 00:00:00 GMT" {
+		t.Fatalf("unexpected Sunset header %q", got)
+	}
+	if got := w.Header().Get("Deprecation"); got != "true" {
+		t.Fatalf("unexpected Deprecation header %q", got)
+	}
+}
+
+func TestCacheControlOption(t *testing.T) {
+	r := router.New()
+	r.GET("/products", func(c *router.Context) {
+		c.JSON(200, []string{})
+	},
+		docs.WithCacheControl("public, max-age=300"),
+		docs.WithResponse(200, "Product list"),
+	)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/products", nil))
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=300" {
+		t.Fatalf("unexpected Cache-Control header %q", got)
+	}
+
+	header, ok := r.Routes()[0].Metadata.Responses["200"].Headers["Cache-Control"]
+	if !ok || header.Schema.Example != "public, max-age=300" {
+		t.Fatalf("expected Cache-Control header to be documented, got %+v", header)
+	}
+}
+
+func TestMaxRequestBytesOption(t *testing.T) {
+	var bindErr error
+	r := router.New()
+	r.Use(func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) {
+			c.SetHeader("X-Chain", "ran")
+			next(c)
+		}
+	})
+	r.POST("/uploads", func(c *router.Context) {
+		var v map[string]string
+		if bindErr = c.BindJSON(&v); bindErr != nil {
+			c.Status(400)
+			return
+		}
+		c.Status(204)
+	},
+		docs.WithDescription("Uploads a document."),
+		docs.WithMaxRequestBytes(16),
+	)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/uploads", strings.NewReader(`{"name":"a long value"}`)))
+	if w.Code != 413 {
+		t.Fatalf("expected 413 for a declared oversized body, got %d", w.Code)
+	}
+	if w.Header().Get("X-Chain") != "ran" {
+		t.Fatal("expected the 413 to go through the router's middleware")
+	}
+
+	req := httptest.NewRequest("POST", "/uploads", io.MultiReader(strings.NewReader(`{"name":"a long value"}`)))
+	req.ContentLength = -1
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	var maxErr *http.MaxBytesError
+	if !errors.As(bindErr, &maxErr) {
+		t.Fatalf("expected reading an oversized body of unknown length to fail, got %v", bindErr)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/uploads", strings.NewReader(`{}`)))
+	if w.Code != 204 {
+		t.Fatalf("expected a small body to be accepted, got %d", w.Code)
+	}
+
+	m := r.Routes()[0].Metadata
+	if m.Extensions["x-max-request-bytes"] != int64(16) {
+		t.Fatalf("expected the limit as an extension, got %v", m.Extensions)
+	}
+	if m.Description != "Uploads a document.\n\nRequest bodies are limited to 16 bytes." {
+		t.Fatalf("expected the limit in the description, got %q", m.Description)
+	}
+}
+
+func TestUseOrderRelativeToGroup(t *testing.T) {
+	marker := func(name string) router.MiddlewareFunc {
+		return func(next router.HandlerFunc) router.HandlerFunc {
+			return func(c *router.Context) {
+				c.AddHeader("X-Middleware", name)
+				next(c)
+			}
+		}
+	}
+	handler := func(c *router.Context) { c.Status(204) }
+
+	r := router.New()
+	r.Use(marker("before"))
+	r.Group("/early", func(g *router.Router) {
+		g.GET("/route", handler)
+	})
+	r.Use(marker("after"))
+	r.Group("/late", func(g *router.Router) {
+		g.GET("/route", handler)
+	})
+
+	tests := map[string][]string{
+		"/early/route": {"before"},
+		"/late/route":  {"before", "after"},
+	}
+	for path, want := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		got := w.Header().Values("X-Middleware")
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("%s: expected middleware %v, got %v", path, want, got)
+		}
+	}
+}
+
+func TestNestedGroupMiddlewareRunsOnce(t *testing.T) {
+	counts := map[string]int{}
+	counter := func(name string) router.MiddlewareFunc {
+		return func(next router.HandlerFunc) router.HandlerFunc {
+			return func(c *router.Context) {
+				counts[name]++
+				next(c)
+			}
+		}
+	}
+
+	r := router.New()
+	r.Use(counter("root"))
+	r.Group("/api", func(api *router.Router) {
+		api.Use(counter("api"))
+		api.Group("/v1", func(v1 *router.Router) {
+			v1.Use(counter("v1"))
+			v1.GET("/users", func(c *router.Context) { c.Status(204) })
+		})
+	})
+
+	for i := 0; i < 3; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/users", nil))
+	}
+
+	for _, name := range []string{"root", "api", "v1"} {
+		if counts[name] != 3 {
+			t.Fatalf("expected %s middleware to run once per request (3), got %d", name, counts[name])
+		}
+	}
+	if n := len(r.Routes()); n != 1 {
+		t.Fatalf("expected the nested route to be recorded once, got %d", n)
+	}
+}
+
+func TestDefaultTimeout(t *testing.T) {
+	deadlines := map[string]bool{}
+	record := func(c *router.Context) {
+		_, ok := c.Context().Deadline()
+		deadlines[c.Request.URL.Path] = ok
+	}
+
+	r := router.New().WithDefaultTimeout(time.Second)
+	var inMiddleware bool
+	r.Use(func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) {
+			_, inMiddleware = c.Context().Deadline()
+			next(c)
+		}
+	})
+	r.GET("/reports", record)
+	r.GET("/events", record, docs.WithNoTimeout())
+	r.Group("/admin", func(admin *router.Router) {
+		admin.GET("/users", record)
+	})
+
+	for _, path := range []string{"/reports", "/events", "/admin/users"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	if !deadlines["/reports"] || !deadlines["/admin/users"] || !inMiddleware {
+		t.Fatalf("expected a deadline on routes and in middleware, got %v", deadlines)
+	}
+	if deadlines["/events"] {
+		t.Fatal("expected no deadline on the route opting out")
+	}
+}
+
+func TestGroupWithoutNamedMiddleware(t *testing.T) {
+	requireAuth := func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) {
+			if c.GetHeader("Authorization") == "" {
+				c.Status(401)
+				return
+			}
+			next(c)
+		}
+	}
+	var logged []string
+	logger := func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) {
+			logged = append(logged, c.Request.URL.Path)
+			next(c)
+		}
+	}
+
+	r := router.New()
+	r.Use(logger)
+	r.UseNamed("auth", requireAuth)
+	r.Group("/public", func(public *router.Router) {
+		public.Without("auth")
+		if n := len(public.Middlewares()); n != 1 {
+			t.Fatalf("expected the group to keep only the logger, got %d middlewares", n)
+		}
+		public.GET("/status", func(c *router.Context) { c.Status(200) })
+	})
+	r.GET("/account", func(c *router.Context) { c.Status(200) })
+
+	if n := len(r.Middlewares()); n != 2 {
+		t.Fatalf("expected the parent to keep both middlewares, got %d", n)
+	}
+	for path, want := range map[string]int{"/public/status": 200, "/account": 401} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != want {
+			t.Errorf("GET %s: expected %d, got %d", path, want, w.Code)
+		}
+	}
+	if len(logged) != 2 {
+		t.Fatalf("expected the logger to run for both routes, got %v", logged)
+	}
+}
+
+func TestRegisterRouteTable(t *testing.T) {
+	r := router.New()
+	r.Register([]router.RouteDef{
+		{Method: "GET", Path: "/items", Handler: func(c *router.Context) { c.Data(200, "text/plain", []byte("list")) }},
+		{Method: "post", Path: "/items", Handler: func(c *router.Context) { c.Status(201) },
+			Options: []router.RouteOption{docs.WithSummary("Create item")}},
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/items", nil))
+	if rec.Code != 200 || rec.Body.String() != "list" {
+		t.Fatalf("GET /items: got %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("POST", "/items", nil))
+	if rec.Code != 201 {
+		t.Fatalf("POST /items: expected 201, got %d", rec.Code)
+	}
+
+	routes := r.Routes()
+	if len(routes) != 2 || routes[1].Method != "POST" || routes[1].Metadata.Summary != "Create item" {
+		t.Fatalf("expected options to be applied to the registered route, got %+v", routes)
+	}
+}
+
+func TestRegisterRejectsUnknownMethod(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Register to panic on an unknown method")
+		}
+	}()
+	router.New().Register([]router.RouteDef{
+		{Method: "FETCH", Path: "/items", Handler: func(c *router.Context) {}},
+	})
+}
+
+func TestMergeSubRouter(t *testing.T) {
+	users := router.New()
+	users.Use(func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) {
+			c.SetHeader("X-Module", "users")
+			next(c)
+		}
+	})
+	users.GET("/users", func(c *router.Context) { c.Status(200) },
+		docs.WithSummary("List users"), docs.WithResponse(200, "Users"))
+
+	r := router.New()
+	r.Merge("/api", users)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/api/users", nil))
+	if rec.Code != 200 {
+		t.Fatalf("expected merged route to be served, got %d", rec.Code)
+	}
+	if rec.Header().Get("X-Module") != "users" {
+		t.Fatal("expected sub-router middleware to run on the merged route")
+	}
+
+	routes := r.Routes()
+	if len(routes) != 1 || routes[0].Path != "/api/users" || routes[0].Metadata.Path != "/api/users" {
+		t.Fatalf("expected docs to reflect /api/users, got %+v", routes)
+	}
+	if routes[0].Metadata.Summary != "List users" {
+		t.Fatalf("expected metadata to be preserved, got %q", routes[0].Metadata.Summary)
+	}
+	if users.Routes()[0].Metadata.Path != "/users" {
+		t.Fatal("expected the sub-router's metadata to be left untouched")
+	}
+
+	docs.WithResponse(404, "Not found")(users.Routes()[0].Metadata)
+	if _, ok := routes[0].Metadata.Responses["404"]; ok {
+		t.Fatal("expected the merged route not to share responses with the sub-router")
+	}
+}
+
+func TestMergedConstraintMismatchRunsMiddleware(t *testing.T) {
+	users := router.New()
+	users.GET("/users/{id:int}", func(c *router.Context) { c.Status(200) })
+
+	r := router.New()
+	r.Use(func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) {
+			c.SetHeader("X-Chain", "ran")
+			next(c)
+		}
+	})
+	r.GET("/items/{id:int}", func(c *router.Context) { c.Status(200) })
+	r.Merge("/api", users)
+
+	for _, target := range []string{"/items/abc", "/api/users/abc"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		if rec.Code != 404 {
+			t.Fatalf("%s: expected 404, got %d", target, rec.Code)
+		}
+		if rec.Header().Get("X-Chain") != "ran" {
+			t.Fatalf("%s: expected the middleware to run on a constraint mismatch", target)
+		}
+	}
+}
+
+func TestNewWithOptions(t *testing.T) {
+	mux := http.NewServeMux()
+	var bindErr error
+	r := router.New(router.WithMux(mux), router.WithMaxBodySize(8))
+	r.POST("/items", func(c *router.Context) {
+		var v map[string]string
+		bindErr = c.BindJSON(&v)
+	})
+
+	// Routes are registered on the provided mux.
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"a long value"}`)))
+
+	var maxErr *http.MaxBytesError
+	if !errors.As(bindErr, &maxErr) {
+		t.Fatalf("expected the body size limit to apply, got %v", bindErr)
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/items", strings.NewReader(`{}`)))
+	if bindErr != nil {
+		t.Fatalf("expected a small body to bind, got %v", bindErr)
+	}
+}
+
+func TestNewWithMultipartMemory(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "data.txt")
+	fw.Write(bytes.Repeat([]byte("x"), 64))
+	mw.Close()
+
+	var onDisk bool
+	r := router.New(router.WithMultipartMemory(16))
+	r.POST("/upload", func(c *router.Context) {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			t.Errorf("FormFile: %v", err)
+			return
+		}
+		f, _ := fh.Open()
+		defer f.Close()
+		_, onDisk = f.(*os.File)
+	})
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !onDisk {
+		t.Fatal("expected a file larger than the multipart memory limit to be stored on disk")
+	}
+}
+
+func TestGroupNotFound(t *testing.T) {
+	message := func(text string) router.HandlerFunc {
+		return func(c *router.Context) { c.Data(404, "text/plain", []byte(text)) }
+	}
+	r := router.New()
+	r.GET("/users", func(c *router.Context) {})
+	r.NotFound(message("site"))
+	r.Group("/api", func(api *router.Router) {
+		api.GET("/orders", func(c *router.Context) {})
+		api.NotFound(message("api"))
+	})
+
+	tests := []struct {
+		method, path string
+		code         int
+		body, allow  string
+	}{
+		{"GET", "/api/missing", 404, "api", ""},
+		{"GET", "/other", 404, "site", ""},
+		{"POST", "/users", 405, `{"detail":"method POST is not allowed for /users","status":405,"title":"Method Not Allowed","type":"about:blank"}`, "GET, HEAD"},
+		{"DELETE", "/api/orders", 405, `{"detail":"method DELETE is not allowed for /api/orders","status":405,"title":"Method Not Allowed","type":"about:blank"}`, "GET, HEAD"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.code || w.Body.String() != tt.body || w.Header().Get("Allow") != tt.allow {
+			t.Errorf("%s %s: expected %d %q with Allow %q, got %d %q with Allow %q",
+				tt.method, tt.path, tt.code, tt.body, tt.allow, w.Code, w.Body.String(), w.Header().Get("Allow"))
+		}
+	}
+
+	groupOnly := router.New()
+	groupOnly.Group("/api", func(api *router.Router) {
+		api.NotFound(message("api"))
+	})
+	w := httptest.NewRecorder()
+	groupOnly.ServeHTTP(w, httptest.NewRequest("GET", "/other", nil))
+	if w.Code != 404 || w.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("expected the default 404 problem outside of the group, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestLookup(t *testing.T) {
+	r := router.New()
+	r.Group("/todos", func(g *router.Router) {
+		g.PUT("/{id}", func(c *router.Context) {}, docs.WithOAuth2Scopes("todos:write"))
+	})
+
+	m, ok := r.Lookup("PUT", "/todos/42")
+	if !ok {
+		t.Fatal("expected PUT /todos/42 to match a route")
+	}
+	if m.Path != "/todos/{id}" {
+		t.Fatalf("expected the matched pattern /todos/{id}, got %s", m.Path)
+	}
+	if len(m.Security) != 1 || len(m.Security[0]["oauth2"]) != 1 || m.Security[0]["oauth2"][0] != "todos:write" {
+		t.Fatalf("expected the route's security requirements, got %v", m.Security)
+	}
+
+	if _, ok := r.Lookup("GET", "/todos/42"); ok {
+		t.Fatal("expected no match for an unregistered method")
+	}
+	if _, ok := r.Lookup("PUT", "/missing"); ok {
+		t.Fatal("expected no match for an unknown path")
+	}
+}
+
+func TestMultipartTempFilesRemovedAfterRequest(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "large.bin")
+	fw.Write(bytes.Repeat([]byte("x"), 1024))
+	mw.Close()
+
+	var tmpName string
+	r := router.New(router.WithMultipartMemory(16))
+	r.POST("/upload", func(c *router.Context) {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			t.Errorf("FormFile: %v", err)
+			return
+		}
+		f, _ := fh.Open()
+		if osFile, ok := f.(*os.File); ok {
+			tmpName = osFile.Name()
+		}
+		f.Close()
+	})
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if tmpName == "" {
+		t.Fatal("expected the upload to spill to a temporary file")
+	}
+	if _, err := os.Stat(tmpName); !os.IsNotExist(err) {
+		t.Fatalf("expected temporary file %s to be removed, got %v", tmpName, err)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	r := router.New()
+	r.Version("v2", func(v2 *router.Router) {
+		v2.GET("/tasks", func(c *router.Context) { c.Status(200) }, docs.WithTags("Tasks"))
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/v2/tasks", nil))
+	if rec.Code != 200 {
+		t.Fatalf("expected the route under /v2, got %d", rec.Code)
+	}
+	if v := rec.Header().Get("X-API-Version"); v != "v2" {
+		t.Fatalf("expected X-API-Version v2, got %q", v)
+	}
+
+	routes := r.Routes()
+	if len(routes) != 1 || routes[0].Path != "/v2/tasks" {
+		t.Fatalf("expected /v2/tasks, got %+v", routes)
+	}
+	tags := routes[0].Metadata.Tags
+	if len(tags) != 2 || tags[0] != "v2" || tags[1] != "Tasks" {
+		t.Fatalf("expected the version tag alongside the route tags, got %v", tags)
+	}
+}
+
+func TestDeprecateVersion(t *testing.T) {
+	r := router.New()
+	r.Version("v1", func(v1 *router.Router) {
+		v1.GET("/tasks", func(c *router.Context) { c.Status(200) })
+		v1.POST("/tasks", func(c *router.Context) { c.Status(201) })
+		v1.Group("/admin", func(admin *router.Router) {
+			admin.GET("/stats", func(c *router.Context) { c.Status(200) })
+		})
+	})
+	r.GET("/v1/legacy", func(c *router.Context) { c.Status(200) })
+	r.Version("v2", func(v2 *router.Router) {
+		v2.GET("/tasks", func(c *router.Context) { c.Status(200) })
+	})
+
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.DeprecateVersion("v1", sunset)
+
+	for _, rt := range r.Routes() {
+		deprecated := strings.HasPrefix(rt.Path, "/v1/") && rt.Path != "/v1/legacy"
+		if rt.Metadata.Deprecated != deprecated {
+			t.Fatalf("%s %s: expected deprecated=%v", rt.Method, rt.Path, deprecated)
+		}
+		if deprecated && !rt.Metadata.Sunset.Equal(sunset) {
+			t.Fatalf("%s %s: expected sunset %v, got %v", rt.Method, rt.Path, sunset, rt.Metadata.Sunset)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/tasks", nil))
+	if rec.Header().Get("Deprecation") != "true" || rec.Header().Get("Sunset") != "Fri, 01 Jan 2027 00:00:00 GMT" {
+		t.Fatalf("expected Deprecation and Sunset headers, got %v", rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/v2/tasks", nil))
+	if rec.Header().Get("Deprecation") != "" {
+		t.Fatal("expected v2 not to be deprecated")
+	}
+}
+
+func TestHandlerAdapter(t *testing.T) {
+	r := router.New(router.WithMaxBodySize(4))
+	r.Use(func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *router.Context) {
+			c.Set("user", "gopher")
+			next(c)
+		}
+	})
+
+	var bindErr error
+	h := r.HandlerAdapter(func(c *router.Context) {
+		var v map[string]string
+		bindErr = c.BindJSON(&v)
+		user, _ := c.Get("user")
+		c.Data(200, "text/plain", []byte(user.(string)))
+	})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/anything", strings.NewReader(`{"too":"long"}`)))
+
+	if rec.Code != 200 || rec.Body.String() != "gopher" {
+		t.Fatalf("expected the middleware to run, got %d %q", rec.Code, rec.Body.String())
+	}
+	var maxErr *http.MaxBytesError
+	if !errors.As(bindErr, &maxErr) {
+		t.Fatalf("expected the router settings to apply, got %v", bindErr)
+	}
+	if len(r.Routes()) != 0 {
+		t.Fatal("expected the adapter not to register a route")
+	}
+
+	// A pooled context must not leak values between requests.
+	rec = httptest.NewRecorder()
+	r.HandlerAdapter(func(c *router.Context) {
+		if _, ok := c.Get("other"); ok {
+			t.Error("unexpected value from a previous request")
+		}
+		c.Set("other", true)
+	}).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+}
@@ -0,0 +1,51 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RequireScopes returns middleware that enforces the OAuth2 scopes documented
+// on the matched route, for example with docs.WithOAuth2Scopes. getScopes
+// returns the scopes granted to the caller, typically read from a token
+// stored on the Context by an authentication middleware.
+//
+// The request is allowed if the caller holds every scope of at least one of
+// the route's security requirements, matching the OpenAPI semantics.
+// Routes without documented scopes are not restricted. Otherwise the
+// middleware responds with 403 Forbidden.
+func RequireScopes(getScopes func(*Context) []string) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			m := c.RouteMetadata()
+			if m == nil || len(m.Security) == 0 {
+				next(c)
+				return
+			}
+
+			granted := make(map[string]bool)
+			for _, scope := range getScopes(c) {
+				granted[scope] = true
+			}
+
+			var missing []string
+			for _, requirement := range m.Security {
+				missing = missing[:0]
+				for _, scopes := range requirement {
+					for _, scope := range scopes {
+						if !granted[scope] {
+							missing = append(missing, scope)
+						}
+					}
+				}
+				if len(missing) == 0 {
+					next(c)
+					return
+				}
+			}
+
+			c.Problem(http.StatusForbidden, ForbiddenProblem(
+				"missing required scope: "+strings.Join(missing, ", ")))
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package router_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/go-router/docs"
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+func TestRequireScopes(t *testing.T) {
+	r := router.New()
+	r.Use(router.RequireScopes(func(c *router.Context) []string {
+		return strings.Fields(c.GetHeader("X-Scopes"))
+	}))
+	r.GET("/todos", func(c *router.Context) { c.Status(200) }, docs.WithOAuth2Scopes("todos:read"))
+	r.POST("/todos", func(c *router.Context) { c.Status(201) }, docs.WithOAuth2Scopes("todos:write"))
+	r.GET("/health", func(c *router.Context) { c.Status(200) })
+
+	tests := []struct {
+		method, path, scopes string
+		want                 int
+	}{
+		{"POST", "/todos", "todos:read", 403},
+		{"POST", "/todos", "todos:read todos:write", 201},
+		{"GET", "/todos", "todos:read", 200},
+		{"GET", "/health", "", 200},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("X-Scopes", tt.scopes)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Fatalf("%s %s with scopes %q: expected %d, got %d", tt.method, tt.path, tt.scopes, tt.want, rec.Code)
+		}
+		if rec.Code == 403 && !strings.Contains(rec.Body.String(), "todos:write") {
+			t.Fatalf("expected the missing scope in the response, got %s", rec.Body.String())
+		}
+	}
+}
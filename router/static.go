@@ -0,0 +1,86 @@
+package router
+
+import (
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/joakimcarlsson/go-router/docs"
+)
+
+// Static serves the files below the root directory under the URL prefix,
+// so that with a prefix of "/assets" the file root/css/site.css is served at
+// "/assets/css/site.css". Directories are served through their index.html.
+// Missing files go through the router's NotFound handler, so clients get the
+// same 404 response as for any other unknown path. The routes are excluded
+// from the API documentation.
+func (r *Router) Static(prefix, root string) {
+	r.StaticFS(prefix, os.DirFS(root))
+}
+
+// StaticFS works like Static but serves the files of fsys, such as an embed.FS.
+func (r *Router) StaticFS(prefix string, fsys fs.FS) {
+	handler := func(c *Context) {
+		name, ok := resolveFile(fsys, c.Param("path"))
+		if !ok {
+			r.notFoundHandler()(c)
+			return
+		}
+		http.ServeFileFS(c.Writer, c.Request, fsys, name)
+	}
+
+	pattern := strings.TrimSuffix(prefix, "/") + "/{path...}"
+	r.GET(pattern, handler, docs.WithExcludeFromDocs())
+}
+
+// resolveFile returns the name of the regular file in fsys that serves the
+// request path p, using index.html for directories, and whether it exists.
+func resolveFile(fsys fs.FS, p string) (string, bool) {
+	name := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if name == "" {
+		name = "."
+	}
+
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return "", false
+	}
+	if info.IsDir() {
+		name = path.Join(name, "index.html")
+		if info, err = fs.Stat(fsys, name); err != nil || info.IsDir() {
+			return "", false
+		}
+	}
+	return name, true
+}
+
+// SPA serves a single-page application from the root directory under the
+// URL prefix. Existing files are served as with Static. Other paths without
+// a file extension, such as the deep link "/app/settings/profile", serve
+// indexFile so the client-side router can handle them, provided the client
+// accepts HTML. Missing assets and requests from API clients go through the
+// router's NotFound handler instead, so unknown API paths keep returning a
+// proper 404. Routes registered on the router take precedence over the SPA.
+func (r *Router) SPA(urlPrefix, root, indexFile string) {
+	fsys := os.DirFS(root)
+	index := strings.TrimPrefix(path.Clean("/"+indexFile), "/")
+
+	handler := func(c *Context) {
+		p := c.Param("path")
+		if name, ok := resolveFile(fsys, p); ok {
+			http.ServeFileFS(c.Writer, c.Request, fsys, name)
+			return
+		}
+		if path.Ext(p) != "" || !strings.Contains(c.GetHeader("Accept"), "text/html") {
+			r.notFoundHandler()(c)
+			return
+		}
+		c.SetHeader("Cache-Control", "no-cache")
+		http.ServeFileFS(c.Writer, c.Request, fsys, index)
+	}
+
+	pattern := strings.TrimSuffix(urlPrefix, "/") + "/{path...}"
+	r.GET(pattern, handler, docs.WithExcludeFromDocs())
+}
@@ -0,0 +1,84 @@
+package router_test
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+func TestStaticUsesNotFoundHandler(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "app.js"), []byte("console.log(1)"), 0o644)
+	os.Mkdir(filepath.Join(root, "docs"), 0o755)
+	os.WriteFile(filepath.Join(root, "docs", "index.html"), []byte("<h1>docs</h1>"), 0o644)
+
+	r := router.New()
+	r.NotFound(func(c *router.Context) {
+		c.JSON(404, map[string]string{"error": "not found"})
+	})
+	r.Static("/assets", root)
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/assets/app.js", 200, "console.log(1)"},
+		{"/assets/docs/", 200, "<h1>docs</h1>"},
+		{"/assets/missing.js", 404, "{\"error\":\"not found\"}\n"},
+		{"/unknown", 404, "{\"error\":\"not found\"}\n"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+		if rec.Code != tt.code || rec.Body.String() != tt.body {
+			t.Fatalf("GET %s: expected %d %q, got %d %q", tt.path, tt.code, tt.body, rec.Code, rec.Body.String())
+		}
+	}
+
+	// The GET route also serves HEAD, so it is the only route registered
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("HEAD", "/assets/app.js", nil))
+	if rec.Code != 200 || rec.Body.Len() != 0 {
+		t.Fatalf("HEAD /assets/app.js: expected 200 without a body, got %d %q", rec.Code, rec.Body.String())
+	}
+	if len(r.Routes()) != 1 || !r.Routes()[0].Metadata.ExcludeFromDocs {
+		t.Fatalf("expected the static route to be excluded from docs, got %+v", r.Routes())
+	}
+}
+
+func TestSPA(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "index.html"), []byte("<div id=app></div>"), 0o644)
+	os.WriteFile(filepath.Join(root, "main.js"), []byte("boot()"), 0o644)
+
+	r := router.New()
+	r.GET("/api/users", func(c *router.Context) { c.JSON(200, []string{"gopher"}) })
+	r.SPA("/", root, "index.html")
+
+	tests := []struct {
+		name, path, accept string
+		code               int
+		body               string
+	}{
+		{"asset", "/main.js", "*/*", 200, "boot()"},
+		{"deep link", "/settings/profile", "text/html,application/xhtml+xml", 200, "<div id=app></div>"},
+		{"missing asset", "/missing.js", "text/html", 404, `{"detail":"/missing.js was not found","status":404,"title":"Not Found","type":"about:blank"}`},
+		{"api route", "/api/users", "application/json", 200, "[\"gopher\"]\n"},
+		{"unknown api path", "/api/orders", "application/json", 404, `{"detail":"/api/orders was not found","status":404,"title":"Not Found","type":"about:blank"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.code || rec.Body.String() != tt.body {
+				t.Fatalf("expected %d %q, got %d %q", tt.code, tt.body, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
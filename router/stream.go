@@ -0,0 +1,169 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// streamBufferSize is the size of the chunks Stream copies and flushes.
+const streamBufferSize = 32 << 10
+
+// Stream copies r to the response with the given content type, flushing
+// after every chunk so clients receive generated content as it is produced,
+// and returns the number of bytes written.
+//
+// When r is an io.ReadSeeker, such as an *os.File or a *bytes.Reader, the
+// response carries a Content-Length and honors a single-range Range header,
+// responding with 206 Partial Content and a Content-Range header, or with
+// 416 Range Not Satisfiable when the range lies outside of the content.
+// The content starts at the reader's current offset. Range headers only
+// apply to GET and HEAD requests, and an If-Range header that doesn't match
+// the ETag or Last-Modified header set on the response, or requests for
+// several ranges, are served the whole content. Other readers are streamed
+// whole with chunked encoding.
+func (c *Context) Stream(contentType string, r io.Reader) (int64, error) {
+	c.SetHeader("Content-Type", contentType)
+
+	seeker, ok := r.(io.ReadSeeker)
+	if !ok {
+		c.Status(http.StatusOK)
+		return c.copyFlushing(r)
+	}
+
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	size := end - offset
+
+	start, length, status := int64(0), size, http.StatusOK
+	if header := c.GetHeader("Range"); header != "" && c.rangeApplies() {
+		var ok bool
+		start, length, ok = parseRange(header, size)
+		switch {
+		case !ok:
+			start, length = 0, size
+		case length == 0:
+			c.SetHeader("Content-Range", fmt.Sprintf("bytes */%d", size))
+			c.Status(http.StatusRequestedRangeNotSatisfiable)
+			return 0, nil
+		default:
+			status = http.StatusPartialContent
+			c.SetHeader("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, size))
+		}
+	}
+	if _, err := seeker.Seek(offset+start, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	c.SetHeader("Accept-Ranges", "bytes")
+	c.SetHeader("Content-Length", strconv.FormatInt(length, 10))
+	c.Status(status)
+	return c.copyFlushing(io.LimitReader(seeker, length))
+}
+
+// rangeApplies reports whether the Range header of the request is honored:
+// the request is a GET or HEAD and its If-Range header, if any, matches the
+// response's strong ETag or its Last-Modified date.
+func (c *Context) rangeApplies() bool {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+	ifRange := c.GetHeader("If-Range")
+	if ifRange == "" {
+		return true
+	}
+	header := c.Writer.Header()
+	if strings.HasPrefix(ifRange, `"`) {
+		return header.Get("ETag") == ifRange
+	}
+	if strings.HasPrefix(ifRange, "W/") {
+		return false
+	}
+	date, err := http.ParseTime(ifRange)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(header.Get("Last-Modified"))
+	return err == nil && date.Equal(modified)
+}
+
+// copyFlushing copies r to the response, flushing after every chunk.
+func (c *Context) copyFlushing(r io.Reader) (int64, error) {
+	controller := http.NewResponseController(c.Writer)
+	buf := make([]byte, streamBufferSize)
+	var written int64
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			w, werr := c.Writer.Write(buf[:n])
+			written += int64(w)
+			if werr != nil {
+				return written, werr
+			}
+			if ferr := controller.Flush(); ferr != nil && !errors.Is(ferr, http.ErrNotSupported) {
+				return written, ferr
+			}
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// parseRange parses a Range header holding a single byte range of content
+// of the given size, returning its start and length. ok is false when the
+// header is malformed or holds several ranges, in which case it is ignored.
+// A length of 0 means the range is not satisfiable.
+func parseRange(header string, size int64) (start, length int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	if first == "" {
+		// A suffix range: the last n bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, false
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, n, true
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false
+	}
+	if start >= size {
+		return 0, 0, true
+	}
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, false
+		}
+		if end > size-1 {
+			end = size - 1
+		}
+	}
+	return start, end - start + 1, true
+}
@@ -0,0 +1,98 @@
+package router_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/go-router/router"
+)
+
+func TestStream(t *testing.T) {
+	const content = "0123456789abcdefghij"
+	r := router.New()
+	r.GET("/seekable", func(c *router.Context) {
+		if _, err := c.Stream("text/plain", strings.NewReader(content)); err != nil {
+			t.Error(err)
+		}
+	})
+	r.GET("/generated", func(c *router.Context) {
+		c.Stream("text/plain", io.MultiReader(strings.NewReader(content[:10]), strings.NewReader(content[10:])))
+	})
+
+	tests := []struct {
+		path, rangeHeader  string
+		status             int
+		body, contentRange string
+	}{
+		{"/seekable", "", 200, content, ""},
+		{"/seekable", "bytes=5-9", 206, "56789", "bytes 5-9/20"},
+		{"/seekable", "bytes=15-", 206, "fghij", "bytes 15-19/20"},
+		{"/seekable", "bytes=-3", 206, "hij", "bytes 17-19/20"},
+		{"/seekable", "bytes=18-40", 206, "ij", "bytes 18-19/20"},
+		{"/seekable", "bytes=0-1,5-6", 200, content, ""},
+		{"/seekable", "bytes=30-", 416, "", "bytes */20"},
+		{"/generated", "bytes=5-9", 200, content, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		if tt.rangeHeader != "" {
+			req.Header.Set("Range", tt.rangeHeader)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s %q: expected %d %q, got %d %q", tt.path, tt.rangeHeader, tt.status, tt.body, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Content-Range"); got != tt.contentRange {
+			t.Errorf("%s %q: expected Content-Range %q, got %q", tt.path, tt.rangeHeader, tt.contentRange, got)
+		}
+		if got := w.Header().Get("Content-Type"); got != "text/plain" {
+			t.Errorf("%s %q: expected Content-Type text/plain, got %q", tt.path, tt.rangeHeader, got)
+		}
+	}
+}
+
+func TestStreamRangeConditions(t *testing.T) {
+	const content = "0123456789abcdefghij"
+	const modified = "Wed, 21 Oct 2015 07:28:00 GMT"
+	r := router.New()
+	handler := func(c *router.Context) {
+		c.SetHeader("ETag", `"v1"`)
+		c.SetHeader("Last-Modified", modified)
+		c.Stream("text/plain", strings.NewReader(content))
+	}
+	r.GET("/file", handler)
+	r.POST("/file", handler)
+
+	tests := []struct {
+		method, ifRange string
+		status          int
+		body            string
+	}{
+		{"GET", "", 206, "56789"},
+		{"HEAD", "", 206, "56789"},
+		{"POST", "", 200, content},
+		{"GET", `"v1"`, 206, "56789"},
+		{"GET", `"v2"`, 200, content},
+		{"GET", `W/"v1"`, 200, content},
+		{"GET", modified, 206, "56789"},
+		{"GET", "Thu, 22 Oct 2015 07:28:00 GMT", 200, content},
+	}
+	// The recorder keeps the body of HEAD responses, which servers drop
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/file", nil)
+		req.Header.Set("Range", "bytes=5-9")
+		if tt.ifRange != "" {
+			req.Header.Set("If-Range", tt.ifRange)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s with If-Range %q: expected %d %q, got %d %q", tt.method, tt.ifRange, tt.status, tt.body, w.Code, w.Body.String())
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package swagger
 
 import (
+	"bytes"
+	_ "embed"
+	"fmt"
 	"html/template"
 	"net/http"
+	"sort"
 
 	"github.com/joakimcarlsson/go-router/metadata"
 )
@@ -50,6 +54,35 @@ type UIConfig struct {
 	CustomJS string
 	// OAuth2Config contains OAuth2 configuration for Swagger UI
 	OAuth2Config *metadata.OAuth2Config
+	// SRIHashes holds Subresource Integrity hashes (e.g. "sha384-...") for the
+	// CDN assets, keyed by file name: "swagger-ui.css", "swagger-ui-bundle.js"
+	// and "swagger-ui-standalone-preset.js". Assets with a hash are loaded with
+	// integrity and crossorigin attributes, so a tampered file is rejected by the browser.
+	// The hashes must match SwaggerVersion.
+	SRIHashes map[string]string
+	// PreauthorizeAPIKey fills in an API key security scheme when the UI has
+	// loaded, so "Try it out" works without authorizing manually. Only use
+	// it for docs that are not public, as the key is part of the page.
+	PreauthorizeAPIKey *APIKeyAuthorization
+	// FaviconURL is the URL of the page's favicon
+	FaviconURL string
+	// LogoURL is the URL of a logo shown in a banner above the UI
+	LogoURL string
+	// LogoAlt is the alternative text of the logo
+	LogoAlt string
+	// Template replaces the built-in HTML page with a custom html/template,
+	// e.g. for a branded layout. It receives the same data as the built-in
+	// page: the fields of this config plus OAuth2QueryParams,
+	// OAuth2LastParam and DarkCSS. Handler panics if it does not parse.
+	Template string
+}
+
+// APIKeyAuthorization is an API key for a security scheme of the spec.
+type APIKeyAuthorization struct {
+	// SchemeName is the name of the apiKey security scheme in the spec
+	SchemeName string
+	// Value is the API key
+	Value string
 }
 
 // DefaultUIConfig returns a default configuration for Swagger UI.
@@ -78,33 +111,44 @@ func DefaultUIConfig() UIConfig {
 	}
 }
 
-// Handler returns an http.HandlerFunc that serves the Swagger UI.
-// It generates an HTML page with Swagger UI configured based on the provided options.
-func Handler(config UIConfig) http.HandlerFunc {
-	const swaggerTemplate = `<!DOCTYPE html>
+// darkCSS is the dark theme inlined into the page when DarkMode is set,
+// so dark mode does not depend on a stylesheet hosted elsewhere.
+//
+//go:embed dark.css
+var darkCSS string
+
+// swaggerTemplate is the HTML page that loads Swagger UI from the CDN.
+const swaggerTemplate = `<!DOCTYPE html>
 <html lang="en">
 <head>
   <meta charset="UTF-8">
   <meta name="viewport" content="width=device-width, initial-scale=1">
   <title>{{.Title}}</title>
-  <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/swagger-ui-dist@{{.SwaggerVersion}}/swagger-ui.css" />
+  {{if .FaviconURL}}
+  <link rel="icon" href="{{.FaviconURL}}" />
+  {{end}}
+  <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/swagger-ui-dist@{{.SwaggerVersion}}/swagger-ui.css"{{with index .SRIHashes "swagger-ui.css"}} integrity="{{.}}" crossorigin="anonymous"{{end}} />
   {{if .DarkMode}}
-  <!-- Using jsDelivr CDN to serve the SwaggerDark CSS with proper MIME type -->
-  <link rel="stylesheet" href="https://cdn.jsdelivr.net/gh/Amoenus/SwaggerDark@master/SwaggerDark.css" />
+  <style>{{.DarkCSS}}</style>
   {{end}}
   <style>
     html { box-sizing: border-box; overflow: -moz-scrollbars-vertical; overflow-y: scroll; }
     *, *:before, *:after { box-sizing: inherit; }
     body { margin: 0; background: {{if .DarkMode}}#1a1a1a{{else}}#fafafa{{end}}; }
     .topbar { display: none; }
+    .docs-banner { padding: 12px 20px; background: {{if .DarkMode}}#262626{{else}}#ffffff{{end}}; border-bottom: 1px solid {{if .DarkMode}}#333333{{else}}#e8e8e8{{end}}; }
+    .docs-banner img { display: block; max-height: 40px; }
     {{.CustomCSS}}
   </style>
 </head>
 <body>
+  {{if .LogoURL}}
+  <div class="docs-banner"><img src="{{.LogoURL}}" alt="{{.LogoAlt}}" /></div>
+  {{end}}
   <div id="swagger-ui"></div>
 
-  <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@{{.SwaggerVersion}}/swagger-ui-bundle.js"></script>
-  <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@{{.SwaggerVersion}}/swagger-ui-standalone-preset.js"></script>
+  <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@{{.SwaggerVersion}}/swagger-ui-bundle.js"{{with index .SRIHashes "swagger-ui-bundle.js"}} integrity="{{.}}" crossorigin="anonymous"{{end}}></script>
+  <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@{{.SwaggerVersion}}/swagger-ui-standalone-preset.js"{{with index .SRIHashes "swagger-ui-standalone-preset.js"}} integrity="{{.}}" crossorigin="anonymous"{{end}}></script>
   <script>
     window.onload = function() {
       // Build the URL with additional query parameters if provided
@@ -124,6 +168,24 @@ func Handler(config UIConfig) http.HandlerFunc {
       }
       {{ end }}
 
+      // Hides the Try it out button of operations with the x-tryItOut: false
+      // extension, set with docs.WithTryItOutDisabled
+      const TryItOutExtensionPlugin = function() {
+        return {
+          wrapComponents: {
+            operation: function(Original, system) {
+              return function(props) {
+                const operation = props.operation;
+                if (operation && operation.getIn(["op", "x-tryItOut"]) === false) {
+                  props = Object.assign({}, props, { operation: operation.set("allowTryItOut", false) });
+                }
+                return system.React.createElement(Original, props);
+              };
+            }
+          }
+        };
+      };
+
       const ui = SwaggerUIBundle({
         url: specUrl,
         dom_id: '#swagger-ui',
@@ -133,7 +195,8 @@ func Handler(config UIConfig) http.HandlerFunc {
           SwaggerUIStandalonePreset
         ],
         plugins: [
-          SwaggerUIBundle.plugins.DownloadUrl
+          SwaggerUIBundle.plugins.DownloadUrl,
+          TryItOutExtensionPlugin
         ],
         layout: "StandaloneLayout",
         defaultModelsExpandDepth: {{.DefaultModelsExpandDepth}},
@@ -160,10 +223,10 @@ func Handler(config UIConfig) http.HandlerFunc {
           {{if .OAuth2Config.AppName}}appName: "{{.OAuth2Config.AppName}}",{{end}}
           {{if .OAuth2Config.ScopeSeparator}}scopeSeparator: "{{.OAuth2Config.ScopeSeparator}}",{{end}}
           {{if .OAuth2Config.Scopes}}scopes: {{.OAuth2Config.Scopes}},{{end}}
-          {{if .OAuth2Config.AdditionalQueryParams}}
+          {{if .OAuth2QueryParams}}
           additionalQueryStringParams: {
-            {{range $key, $value := .OAuth2Config.AdditionalQueryParams}}
-            "{{$key}}": "{{$value}}"{{if not (last $key $.OAuth2Config.AdditionalQueryParams)}},{{end}}
+            {{range $i, $p := .OAuth2QueryParams}}
+            "{{$p.Key}}": "{{$p.Value}}"{{if lt $i $.OAuth2LastParam}},{{end}}
             {{end}}
           },
           {{end}}
@@ -171,6 +234,11 @@ func Handler(config UIConfig) http.HandlerFunc {
           useBasicAuthenticationWithAccessCodeGrant: {{.OAuth2Config.UseBasicAuthenticationWithAccessCodeGrant}}
         }
         {{end}}
+        {{with .PreauthorizeAPIKey}},
+        onComplete: function() {
+          ui.preauthorizeApiKey("{{.SchemeName}}", "{{.Value}}");
+        }
+        {{end}}
       });
       window.ui = ui;
       
@@ -180,65 +248,115 @@ func Handler(config UIConfig) http.HandlerFunc {
 </body>
 </html>`
 
-	tmpl, err := template.New("swagger-ui").Funcs(template.FuncMap{
-		"last": func(key string, m map[string]string) bool {
-			// Get all keys and find if this is the last one
-			keys := make([]string, 0, len(m))
-			for k := range m {
-				keys = append(keys, k)
-			}
-			return len(keys) > 0 && keys[len(keys)-1] == key
-		},
-	}).Parse(swaggerTemplate)
-	if err != nil {
-		panic(err)
+// uiTemplate is the compiled Swagger UI page. It is parsed once when the
+// package is initialized, so a broken template fails at startup instead of
+// on the first request, and is shared by every handler.
+var uiTemplate = template.Must(template.New("swagger-ui").Parse(swaggerTemplate))
+
+// queryParam is a single key/value pair rendered into the page.
+type queryParam struct {
+	Key   string
+	Value string
+}
+
+// sortedQueryParams returns the params ordered by key, so the page can range
+// over a slice and knows the last index up front.
+func sortedQueryParams(m map[string]string) []queryParam {
+	params := make([]queryParam, 0, len(m))
+	for key, value := range m {
+		params = append(params, queryParam{Key: key, Value: value})
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Key < params[j].Key })
+	return params
+}
+
+// pageData is the data the Swagger UI page is rendered with.
+type pageData struct {
+	Title                    string
+	SpecURL                  string
+	SwaggerVersion           string
+	DarkMode                 bool
+	PersistAuthorization     bool
+	DefaultModelsExpandDepth int
+	DeepLinking              bool
+	DocExpansion             string
+	Filter                   bool
+	AdditionalQueryParams    map[string]string
+	DisplayRequestDuration   bool
+	MaxDisplayedTags         int
+	ShowExtensions           bool
+	TryItOutEnabled          bool
+	RequestSnippetsEnabled   bool
+	DefaultModelRendering    string
+	CustomCSS                string
+	CustomJS                 string
+	OAuth2Config             *metadata.OAuth2Config
+	SRIHashes                map[string]string
+	FaviconURL               string
+	LogoURL                  string
+	LogoAlt                  string
+	DarkCSS                  template.CSS
+	PreauthorizeAPIKey       *APIKeyAuthorization
+	OAuth2QueryParams        []queryParam
+	OAuth2LastParam          int
+}
+
+// Handler returns an http.HandlerFunc that serves the Swagger UI.
+// It generates an HTML page with Swagger UI configured based on the provided options.
+// The page is rendered into a buffer first, so a rendering error results in a
+// 500 response rather than a partially written page.
+// It panics if config.Template is set but does not parse.
+func Handler(config UIConfig) http.HandlerFunc {
+	tmpl := uiTemplate
+	if config.Template != "" {
+		custom, err := template.New("swagger-ui").Parse(config.Template)
+		if err != nil {
+			panic(fmt.Sprintf("swagger: invalid UI template: %v", err))
+		}
+		tmpl = custom
+	}
+
+	data := pageData{
+		Title:                    config.Title,
+		SpecURL:                  config.SpecURL,
+		SwaggerVersion:           config.SwaggerVersion,
+		DarkMode:                 config.DarkMode,
+		PersistAuthorization:     config.PersistAuthorization,
+		DefaultModelsExpandDepth: config.DefaultModelsExpandDepth,
+		DeepLinking:              config.DeepLinking,
+		DocExpansion:             config.DocExpansion,
+		Filter:                   config.Filter,
+		AdditionalQueryParams:    config.AdditionalQueryParams,
+		DisplayRequestDuration:   config.DisplayRequestDuration,
+		MaxDisplayedTags:         config.MaxDisplayedTags,
+		ShowExtensions:           config.ShowExtensions,
+		TryItOutEnabled:          config.TryItOutEnabled,
+		RequestSnippetsEnabled:   config.RequestSnippetsEnabled,
+		DefaultModelRendering:    config.DefaultModelRendering,
+		CustomCSS:                config.CustomCSS,
+		CustomJS:                 config.CustomJS,
+		OAuth2Config:             config.OAuth2Config,
+		SRIHashes:                config.SRIHashes,
+		FaviconURL:               config.FaviconURL,
+		LogoURL:                  config.LogoURL,
+		LogoAlt:                  config.LogoAlt,
+		DarkCSS:                  template.CSS(darkCSS),
+		PreauthorizeAPIKey:       config.PreauthorizeAPIKey,
+	}
+	if config.OAuth2Config != nil {
+		data.OAuth2QueryParams = sortedQueryParams(config.OAuth2Config.AdditionalQueryParams)
+		data.OAuth2LastParam = len(data.OAuth2QueryParams) - 1
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		data := struct {
-			Title                    string
-			SpecURL                  string
-			SwaggerVersion           string
-			DarkMode                 bool
-			PersistAuthorization     bool
-			DefaultModelsExpandDepth int
-			DeepLinking              bool
-			DocExpansion             string
-			Filter                   bool
-			AdditionalQueryParams    map[string]string
-			DisplayRequestDuration   bool
-			MaxDisplayedTags         int
-			ShowExtensions           bool
-			TryItOutEnabled          bool
-			RequestSnippetsEnabled   bool
-			DefaultModelRendering    string
-			CustomCSS                string
-			CustomJS                 string
-			OAuth2Config             *metadata.OAuth2Config
-		}{
-			Title:                    config.Title,
-			SpecURL:                  config.SpecURL,
-			SwaggerVersion:           config.SwaggerVersion,
-			DarkMode:                 config.DarkMode,
-			PersistAuthorization:     config.PersistAuthorization,
-			DefaultModelsExpandDepth: config.DefaultModelsExpandDepth,
-			DeepLinking:              config.DeepLinking,
-			DocExpansion:             config.DocExpansion,
-			Filter:                   config.Filter,
-			AdditionalQueryParams:    config.AdditionalQueryParams,
-			DisplayRequestDuration:   config.DisplayRequestDuration,
-			MaxDisplayedTags:         config.MaxDisplayedTags,
-			ShowExtensions:           config.ShowExtensions,
-			TryItOutEnabled:          config.TryItOutEnabled,
-			RequestSnippetsEnabled:   config.RequestSnippetsEnabled,
-			DefaultModelRendering:    config.DefaultModelRendering,
-			CustomCSS:                config.CustomCSS,
-			CustomJS:                 config.CustomJS,
-			OAuth2Config:             config.OAuth2Config,
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			http.Error(w, "failed to render Swagger UI", http.StatusInternalServerError)
+			return
 		}
 
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
-		tmpl.Execute(w, data)
+		w.Write(buf.Bytes())
 	}
 }
@@ -0,0 +1,211 @@
+package swagger_test
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/joakimcarlsson/go-router/metadata"
+	"github.com/joakimcarlsson/go-router/swagger"
+)
+
+// render serves the Swagger UI page for config and returns the body.
+func render(t testing.TB, config swagger.UIConfig) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	swagger.Handler(config)(w, httptest.NewRequest("GET", "/docs", nil))
+	if w.Code != 200 {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	return w.Body.String()
+}
+
+func oauth2ConfigWithParams(n int) swagger.UIConfig {
+	oauth := metadata.NewOAuth2Config().WithClientID("client")
+	for i := 0; i < n; i++ {
+		oauth.WithAdditionalQueryParam(fmt.Sprintf("param%02d", i), "value")
+	}
+	config := swagger.DefaultUIConfig()
+	config.OAuth2Config = oauth
+	return config
+}
+
+func TestHandlerOAuth2QueryParams(t *testing.T) {
+	body := render(t, oauth2ConfigWithParams(3))
+
+	block := regexp.MustCompile(`(?s)additionalQueryStringParams: \{(.*?)\}`).FindStringSubmatch(body)
+	if block == nil {
+		t.Fatal("expected additionalQueryStringParams to be rendered")
+	}
+	fields := strings.Fields(block[1])
+	expected := []string{`"param00":`, `"value",`, `"param01":`, `"value",`, `"param02":`, `"value"`}
+	if strings.Join(fields, " ") != strings.Join(expected, " ") {
+		t.Fatalf("expected params in key order without a trailing comma, got %q", block[1])
+	}
+}
+
+// mapLastTemplate renders the params the way the page used to, scanning the
+// map keys for every entry to decide whether to emit a comma.
+var mapLastTemplate = template.Must(template.New("params").Funcs(template.FuncMap{
+	"last": func(key string, m map[string]string) bool {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		return len(keys) > 0 && keys[len(keys)-1] == key
+	},
+}).Parse(`{{range $key, $value := .}}"{{$key}}": "{{$value}}"{{if not (last $key $)}},{{end}}{{end}}`))
+
+func BenchmarkOAuth2QueryParamsMapLast(b *testing.B) {
+	params := oauth2ConfigWithParams(20).OAuth2Config.AdditionalQueryParams
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		mapLastTemplate.Execute(io.Discard, params)
+	}
+}
+
+func BenchmarkOAuth2QueryParamsSlice(b *testing.B) {
+	type param struct{ Key, Value string }
+	params := make([]param, 0, 20)
+	for key, value := range oauth2ConfigWithParams(20).OAuth2Config.AdditionalQueryParams {
+		params = append(params, param{key, value})
+	}
+	tmpl := template.Must(template.New("params").Parse(
+		`{{range $i, $p := .Params}}"{{$p.Key}}": "{{$p.Value}}"{{if lt $i $.Last}},{{end}}{{end}}`))
+	data := struct {
+		Params []param
+		Last   int
+	}{params, len(params) - 1}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tmpl.Execute(io.Discard, data)
+	}
+}
+
+func BenchmarkHandler(b *testing.B) {
+	handler := swagger.Handler(oauth2ConfigWithParams(20))
+	req := httptest.NewRequest("GET", "/docs", nil)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		handler(httptest.NewRecorder(), req)
+	}
+}
+
+func TestHandlerSRIHashes(t *testing.T) {
+	config := swagger.DefaultUIConfig()
+	body := render(t, config)
+	if strings.Contains(body, "integrity=") {
+		t.Fatal("expected no integrity attributes without hashes")
+	}
+
+	config.SRIHashes = map[string]string{
+		"swagger-ui.css":                  "sha384-css",
+		"swagger-ui-bundle.js":            "sha384-bundle",
+		"swagger-ui-standalone-preset.js": "sha384-preset",
+	}
+	body = render(t, config)
+	for _, want := range []string{
+		`swagger-ui.css" integrity="sha384-css" crossorigin="anonymous"`,
+		`swagger-ui-bundle.js" integrity="sha384-bundle" crossorigin="anonymous"`,
+		`swagger-ui-standalone-preset.js" integrity="sha384-preset" crossorigin="anonymous"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected page to contain %s", want)
+		}
+	}
+}
+
+func TestHandlerCustomTemplate(t *testing.T) {
+	config := swagger.DefaultUIConfig()
+	config.Title = "Acme API"
+	config.Template = `<html><body><header class="acme-nav">{{.Title}}</header><div data-spec="{{.SpecURL}}"></div></body></html>`
+
+	body := render(t, config)
+	if !strings.Contains(body, `<header class="acme-nav">Acme API</header>`) {
+		t.Fatalf("expected the custom markup, got %q", body)
+	}
+	if !strings.Contains(body, `data-spec="/openapi.json"`) {
+		t.Fatalf("expected the spec URL to be rendered, got %q", body)
+	}
+	if strings.Contains(body, "swagger-ui-bundle.js") {
+		t.Fatal("expected the built-in page to be replaced")
+	}
+}
+
+func TestHandlerInvalidTemplatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Handler to panic on an invalid template")
+		}
+	}()
+	config := swagger.DefaultUIConfig()
+	config.Template = `{{.Title`
+	swagger.Handler(config)
+}
+
+func TestHandlerFaviconAndLogo(t *testing.T) {
+	body := render(t, swagger.DefaultUIConfig())
+	if strings.Contains(body, `rel="icon"`) || strings.Contains(body, `class="docs-banner"`) {
+		t.Fatal("expected no favicon or logo by default")
+	}
+
+	config := swagger.DefaultUIConfig()
+	config.FaviconURL = "/static/favicon.ico"
+	config.LogoURL = "/static/logo.svg"
+	config.LogoAlt = "Acme"
+
+	body = render(t, config)
+	if !strings.Contains(body, `<link rel="icon" href="/static/favicon.ico" />`) {
+		t.Fatalf("expected the favicon link, got %q", body)
+	}
+	if !strings.Contains(body, `<img src="/static/logo.svg" alt="Acme" />`) {
+		t.Fatalf("expected the logo image, got %q", body)
+	}
+}
+
+func TestHandlerDarkModeIsSelfContained(t *testing.T) {
+	config := swagger.DefaultUIConfig()
+	config.DarkMode = true
+	body := render(t, config)
+
+	if strings.Contains(body, "SwaggerDark") || strings.Contains(body, "/gh/") {
+		t.Fatal("expected no reference to the external dark theme")
+	}
+	if !strings.Contains(body, ".swagger-ui .opblock-body pre.microlight") {
+		t.Fatal("expected the embedded dark styles to be inlined")
+	}
+
+	config.DarkMode = false
+	if strings.Contains(render(t, config), ".swagger-ui .opblock-body pre.microlight") {
+		t.Fatal("expected no dark styles when dark mode is off")
+	}
+}
+
+func TestHandlerPreauthorizeAPIKey(t *testing.T) {
+	if strings.Contains(render(t, swagger.DefaultUIConfig()), "preauthorizeApiKey") {
+		t.Fatal("expected no preauthorization by default")
+	}
+
+	config := swagger.DefaultUIConfig()
+	config.PreauthorizeAPIKey = &swagger.APIKeyAuthorization{SchemeName: "apiKey", Value: "dev-key"}
+	body := render(t, config)
+	if !strings.Contains(body, `ui.preauthorizeApiKey("apiKey", "dev-key");`) {
+		t.Fatalf("expected the preauthorize call, got %q", body)
+	}
+	if !regexp.MustCompile(`defaultModelRendering: "model"\s*,\s*onComplete: function\(\)`).MatchString(body) {
+		t.Fatal("expected onComplete to be added to the UI options")
+	}
+}
+
+func TestHandlerHonorsTryItOutExtension(t *testing.T) {
+	body := render(t, swagger.DefaultUIConfig())
+	if !strings.Contains(body, `getIn(["op", "x-tryItOut"]) === false`) || !strings.Contains(body, "TryItOutExtensionPlugin\n") {
+		t.Fatal("expected the plugin honoring x-tryItOut to be installed")
+	}
+}